@@ -0,0 +1,132 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"time"
+
+	timeentry "github.com/gyurkovicsferi/time-tracker/lib"
+	"github.com/gyurkovicsferi/time-tracker/lib/db"
+	"github.com/gyurkovicsferi/time-tracker/lib/editfile"
+	libStore "github.com/gyurkovicsferi/time-tracker/lib/store"
+	"github.com/urfave/cli/v3"
+)
+
+var AmendCmd = &cli.Command{
+	Name:        "amend",
+	Usage:       "Change the running time entry",
+	Description: "Fix the project, task, start or note of the running time entry without stopping it. Without flags it is edited in $EDITOR.",
+	Category:    "time-entry",
+	Flags: []cli.Flag{
+		&cli.StringFlag{
+			Name:  "project",
+			Usage: "New project",
+		},
+		&cli.StringFlag{
+			Name:  "task",
+			Usage: "New task",
+		},
+		&cli.StringFlag{
+			Name:  "from",
+			Usage: "New start, e.g. 09:30 (today) or 2006-01-02 09:30",
+		},
+		&cli.StringFlag{
+			Name:  "note",
+			Usage: "New note",
+		},
+	},
+	Action: func(ctx context.Context, cmd *cli.Command) error {
+		db := db.NewDB()
+
+		store := libStore.NewStore(db)
+		defer store.Close()
+
+		current := store.GetCurrentTimeEntry()
+		if current == nil {
+			return fmt.Errorf("no running time entry")
+		}
+
+		return amendRunning(cmd, store, current, "from")
+	},
+}
+
+// Changes the running time entry with the flags or, without them, in the
+// editor. startFlag is the name of the flag of the new start.
+func amendRunning(cmd *cli.Command, store *libStore.Store, current *libStore.CurrentTimeEntry, startFlag string) error {
+	var amended *libStore.CurrentTimeEntry
+	var err error
+	if slices.ContainsFunc([]string{"project", "task", startFlag, "note"}, func(flag string) bool { return HasFlag(cmd, flag) }) {
+		amended, err = amendWithFlags(cmd, current, startFlag)
+	} else {
+		amended, err = amendInEditor(store, current)
+	}
+	if err != nil {
+		return err
+	}
+
+	if err := timeentry.Amend(store, amended); err != nil {
+		return err
+	}
+
+	printChanges(runningAsTimeEntry(current), runningAsTimeEntry(amended))
+	return nil
+}
+
+func amendWithFlags(cmd *cli.Command, current *libStore.CurrentTimeEntry, startFlag string) (*libStore.CurrentTimeEntry, error) {
+	amended := *current
+
+	if HasFlag(cmd, "project") {
+		amended.Project = cmd.String("project")
+	}
+	if HasFlag(cmd, "task") {
+		amended.Task = cmd.String("task")
+	}
+	if HasFlag(cmd, "note") {
+		amended.Note = cmd.String("note")
+	}
+	if HasFlag(cmd, startFlag) {
+		start, err := parseTime(cmd.String(startFlag), time.Now())
+		if err != nil {
+			return nil, err
+		}
+		amended.Start = start
+	}
+
+	return &amended, nil
+}
+
+func amendInEditor(store *libStore.Store, current *libStore.CurrentTimeEntry) (*libStore.CurrentTimeEntry, error) {
+	content, err := editfile.FormatRunning(current,
+		"Modify this file to change the running time entry, the id can't be changed",
+		"Times are like 2006-01-02T15:04:05+02:00 or 2006-01-02 15:04 (local time)",
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	var amended *libStore.CurrentTimeEntry
+	err = editUntilValid(content, func(edited string) error {
+		amended, err = editfile.ParseRunning(edited, current)
+		if err != nil {
+			return err
+		}
+		if amended.Start.After(time.Now()) {
+			return fmt.Errorf("start time is in the future")
+		}
+		return nil
+	})
+	return amended, err
+}
+
+// For printing the changes, the end is left empty
+func runningAsTimeEntry(current *libStore.CurrentTimeEntry) *libStore.TimeEntry {
+	return &libStore.TimeEntry{
+		ID:      current.ID,
+		Project: current.Project,
+		Task:    current.Task,
+		Note:    current.Note,
+		Tags:    current.Tags,
+		Start:   current.Start,
+	}
+}
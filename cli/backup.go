@@ -0,0 +1,177 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gyurkovicsferi/time-tracker/lib/backup"
+	"github.com/gyurkovicsferi/time-tracker/lib/db"
+	"github.com/ostafen/clover/v2"
+	"github.com/pterm/pterm"
+	"github.com/urfave/cli/v3"
+)
+
+var BackupCmd = &cli.Command{
+	Name:        "backup",
+	Usage:       "backup --out <file.json.gz>",
+	Description: "Back up the whole database (time entries, running entry, sync queue and ledger, configuration) to a JSON file. The file contains the stored API keys, keep it private.",
+	Category:    "data",
+	Flags: []cli.Flag{
+		&cli.StringFlag{
+			Name:     "out",
+			Aliases:  []string{"o"},
+			Usage:    "Backup file, gzipped when it ends with .gz",
+			Required: true,
+		},
+	},
+	Action: func(ctx context.Context, cmd *cli.Command) error {
+		newDb := db.NewDB()
+		defer newDb.Close()
+
+		path := cmd.String("out")
+		b, err := writeBackup(newDb, path)
+		if err != nil {
+			return err
+		}
+
+		printBackupCounts(b.Counts())
+		pterm.Success.Println("Backed up to " + path)
+		return nil
+	},
+}
+
+var RestoreCmd = &cli.Command{
+	Name:        "restore",
+	Usage:       "restore <file> [--mode merge|replace]",
+	Description: "Restore a backup. merge adds what doesn't exist yet, replace deletes everything first (after saving the current data to a backup).",
+	ArgsUsage:   "<file>",
+	Category:    "data",
+	Flags: []cli.Flag{
+		&cli.StringFlag{
+			Name:  "mode",
+			Usage: "merge or replace",
+			Value: string(backup.ModeMerge),
+		},
+		&cli.BoolFlag{
+			Name:  "dry-run",
+			Usage: "Only validate the backup and show what it contains",
+		},
+		&cli.BoolFlag{
+			Name:    "yes",
+			Aliases: []string{"y"},
+			Usage:   "Replace without asking for confirmation",
+		},
+	},
+	Action: func(ctx context.Context, cmd *cli.Command) error {
+		path := cmd.Args().First()
+		if path == "" {
+			return fmt.Errorf("file is required")
+		}
+
+		mode := backup.Mode(cmd.String("mode"))
+		if mode != backup.ModeMerge && mode != backup.ModeReplace {
+			return fmt.Errorf("unknown mode %q, expected merge or replace", mode)
+		}
+
+		file, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+
+		b, err := backup.Read(file)
+		if err != nil {
+			return err
+		}
+		if err := b.Validate(); err != nil {
+			return fmt.Errorf("invalid backup: %v", err)
+		}
+
+		pterm.Printfln("Backup of %s (schema version %d)", b.CreatedAt.Format(time.DateTime), b.SchemaVersion)
+		printBackupCounts(b.Counts())
+		if cmd.Bool("dry-run") {
+			return nil
+		}
+
+		if mode == backup.ModeReplace && !cmd.Bool("yes") {
+			confirmed, err := pterm.DefaultInteractiveConfirm.Show("Replace all the current data with the backup?")
+			if err != nil {
+				return err
+			}
+			if !confirmed {
+				return nil
+			}
+		}
+
+		newDb := db.NewDB()
+		defer newDb.Close()
+
+		if mode == backup.ModeReplace {
+			safetyPath := filepath.Join(backupsDir(), "before-restore-"+time.Now().Format("20060102-150405")+".json.gz")
+			if _, err := writeBackup(newDb, safetyPath); err != nil {
+				return fmt.Errorf("failed to back up the current data, nothing was restored: %v", err)
+			}
+			pterm.Println("Current data backed up to " + safetyPath)
+		}
+
+		restored, err := b.Restore(newDb, mode)
+		if err != nil {
+			return err
+		}
+
+		total := 0
+		for _, count := range restored {
+			total += count
+		}
+		pterm.Success.Printfln("Restored %d documents", total)
+		return nil
+	},
+}
+
+// Backups made before destructive operations are kept next to the database
+func backupsDir() string {
+	return filepath.Join(filepath.Dir(db.GetDefaultPath()), "backups")
+}
+
+func writeBackup(newDb *clover.DB, path string) (*backup.Backup, error) {
+	b, err := backup.Create(newDb)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return nil, err
+	}
+
+	// Private, as it contains the API keys
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := b.Write(file, strings.HasSuffix(path, ".gz")); err != nil {
+		file.Close()
+		return nil, fmt.Errorf("failed to write %s: %v", path, err)
+	}
+	return b, file.Close()
+}
+
+func printBackupCounts(counts map[string]int) {
+	names := make([]string, 0, len(counts))
+	for name := range counts {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+
+	table := pterm.TableData{{"Collection", "Documents"}}
+	for _, name := range names {
+		table = append(table, []string{name, strconv.Itoa(counts[name])})
+	}
+	pterm.DefaultTable.WithHasHeader().WithData(table).Render()
+}
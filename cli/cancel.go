@@ -0,0 +1,58 @@
+package main
+
+import (
+	"context"
+	"fmt"
+
+	timeentry "github.com/gyurkovicsferi/time-tracker/lib"
+	"github.com/gyurkovicsferi/time-tracker/lib/db"
+	"github.com/gyurkovicsferi/time-tracker/lib/provider"
+	libStore "github.com/gyurkovicsferi/time-tracker/lib/store"
+	"github.com/pterm/pterm"
+	"github.com/urfave/cli/v3"
+)
+
+var CancelCmd = &cli.Command{
+	Name:        "cancel",
+	Usage:       "Discard the running time entry without recording it",
+	Description: "Discard an accidentally started time entry. With --restore the time entry it stopped when it was started is running again.",
+	Category:    "time-entry",
+	Flags: []cli.Flag{
+		&cli.BoolFlag{
+			Name:  "restore",
+			Usage: "Resume the time entry that was stopped when the running one started",
+		},
+	},
+	Action: func(ctx context.Context, cmd *cli.Command) error {
+		db := db.NewDB()
+
+		store := libStore.NewStore(db)
+		defer store.Close()
+
+		current := store.GetCurrentTimeEntry()
+		if current == nil {
+			return fmt.Errorf("no running time entry")
+		}
+
+		restored, err := timeentry.Cancel(store, current, cmd.Bool("restore"))
+		if err != nil {
+			return err
+		}
+		pterm.Println("Cancelled time entry: ", current.Project, current.Task)
+
+		if restored == nil {
+			if current.Stopped != "" && store.GetTimeEntry(current.Stopped) != nil {
+				pterm.Info.Println("Use cancel --restore to resume the time entry it stopped")
+			}
+			return nil
+		}
+
+		// Deleted from the finished time entries while it runs again
+		if err := provider.NewLedgerStore(db).MarkDeleted(restored.ID); err != nil {
+			return err
+		}
+		printStatus(restored, false)
+		pterm.Println()
+		return nil
+	},
+}
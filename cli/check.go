@@ -0,0 +1,198 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	timeentry "github.com/gyurkovicsferi/time-tracker/lib"
+	"github.com/gyurkovicsferi/time-tracker/lib/db"
+	libStore "github.com/gyurkovicsferi/time-tracker/lib/store"
+	"github.com/pterm/pterm"
+	"github.com/urfave/cli/v3"
+	"golang.org/x/term"
+)
+
+var CheckCmd = &cli.Command{
+	Name:        "check",
+	Usage:       "Find suspicious time entries",
+	Description: "The limits are set with config set --max-entry-length and --idle-threshold",
+	Category:    "reporting",
+	Commands: []*cli.Command{
+		{
+			Name:        "long-entries",
+			Usage:       "List the time entries that were probably left running",
+			Description: "List the time entries longer than the maximum entry length or running longer than the idle threshold after the end of the working hours. Fix them with edit or split.",
+			Flags: []cli.Flag{
+				&cli.TimestampFlag{
+					Name:  "from",
+					Usage: "From date (default 30 days ago)",
+					Config: cli.TimestampConfig{
+						Timezone: time.Local,
+						Layouts:  []string{"2006-01-02"},
+					},
+				},
+				&cli.TimestampFlag{
+					Name:  "to",
+					Usage: "To date (inclusive, default today)",
+					Config: cli.TimestampConfig{
+						Timezone: time.Local,
+						Layouts:  []string{"2006-01-02"},
+					},
+				},
+			},
+			Action: func(ctx context.Context, cmd *cli.Command) error {
+				store := libStore.NewStore(db.NewDB())
+				defer store.Close()
+
+				from := libStore.StartOfDay(time.Now().AddDate(0, 0, -30))
+				to := libStore.EndOfDay(time.Now())
+				if HasFlag(cmd, "from") {
+					from = libStore.StartOfDay(cmd.Timestamp("from"))
+				}
+				if HasFlag(cmd, "to") {
+					to = libStore.EndOfDay(cmd.Timestamp("to"))
+				}
+				if to.Before(from) {
+					return fmt.Errorf("--to is before --from")
+				}
+
+				settings := store.GetSettings()
+				long := timeentry.LongEntries(store, settings, from, to)
+				if len(long) == 0 {
+					pterm.Success.Println("No long time entries")
+					return nil
+				}
+
+				table := pterm.TableData{{"ID", "Project", "Task", "Start", "End", "Duration", "Problem", "Probable end"}}
+				for _, entry := range long {
+					table = append(table, []string{
+						entry.TimeEntry.ID,
+						entry.TimeEntry.Project,
+						entry.TimeEntry.Task,
+						entry.TimeEntry.Start.Local().Format(time.DateTime),
+						entry.TimeEntry.End.Local().Format(time.DateTime),
+						formatDuration(entry.TimeEntry.End.Sub(entry.TimeEntry.Start)),
+						describeLongEntry(settings, entry.LongEntry, entry.TimeEntry.End),
+						entry.SuggestedEnd.Local().Format(time.DateTime),
+					})
+				}
+				pterm.DefaultTable.WithHasHeader().WithData(table).Render()
+				pterm.Info.Printfln("%d long time entries, fix them with edit <id> --end or split <id> --at", len(long))
+				return nil
+			},
+		},
+	},
+}
+
+func describeLongEntry(settings *libStore.Settings, check *timeentry.LongEntry, end time.Time) string {
+	if check.Idle {
+		return fmt.Sprintf("%s after the end of the working hours (%s)", formatDuration(end.Sub(check.SuggestedEnd)), settings.WorkEnd)
+	}
+	return fmt.Sprintf("longer than %s", formatDuration(settings.MaxEntryDuration()))
+}
+
+// Warns if the running time entry, ending at end, looks like it was left
+// running and offers to stop it earlier or split it. With stopping, the time
+// entry is being stopped at end, otherwise it keeps running if it is kept.
+// Returns true if the running time entry was stopped or replaced.
+func checkRunningEntry(store *libStore.Store, current *libStore.CurrentTimeEntry, end time.Time, stopping bool) (bool, error) {
+	settings := store.GetSettings()
+	check := timeentry.CheckLength(settings, current.Start, end)
+	if check == nil {
+		return false, nil
+	}
+
+	pterm.Warning.Printfln("%s / %s has been running since %s, %s",
+		current.Project, current.Task, current.Start.Local().Format(time.DateTime), describeLongEntry(settings, check, end))
+
+	// Scripts don't get asked
+	if !term.IsTerminal(int(os.Stdin.Fd())) {
+		return false, nil
+	}
+
+	stopAtSuggested := "Stop it at " + check.SuggestedEnd.Local().Format(time.DateTime)
+	stopAt := "Stop it at another time"
+	split := "Split it, tracking the rest as another task"
+	keep := "Keep it running"
+	if stopping {
+		keep = "Keep it until " + end.Local().Format(time.DateTime)
+	}
+
+	selected, err := pterm.DefaultInteractiveSelect.
+		WithOptions([]string{stopAtSuggested, stopAt, split, keep}).
+		WithDefaultText("Was it left running?").
+		Show()
+	if err != nil {
+		return false, err
+	}
+
+	switch selected {
+	case stopAtSuggested:
+		stopped := timeentry.Stop(store, current, check.SuggestedEnd)
+		pterm.Success.Printfln("Stopped %s / %s at %s", stopped.Project, stopped.Task, stopped.End.Local().Format(time.DateTime))
+		return true, nil
+	case stopAt:
+		at, err := askTime("Stop at", check.SuggestedEnd, current.Start, end)
+		if err != nil {
+			return false, err
+		}
+		stopped := timeentry.Stop(store, current, at)
+		pterm.Success.Printfln("Stopped %s / %s at %s", stopped.Project, stopped.Task, stopped.End.Local().Format(time.DateTime))
+		return true, nil
+	case split:
+		at, err := askTime("Split at", check.SuggestedEnd, current.Start, end)
+		if err != nil {
+			return false, err
+		}
+		task, err := pterm.DefaultInteractiveTextInput.WithDefaultValue(current.Task).Show("Task of the rest")
+		if err != nil {
+			return false, err
+		}
+		return true, splitRunningEntry(store, current, at, end, task, stopping)
+	}
+	return false, nil
+}
+
+// Asks for a time between start and end
+func askTime(text string, suggested, start, end time.Time) (time.Time, error) {
+	value, err := pterm.DefaultInteractiveTextInput.WithDefaultValue(suggested.Local().Format("2006-01-02 15:04")).Show(text)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	at, err := parseTime(value, suggested)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if !at.After(start) || !at.Before(end) {
+		return time.Time{}, fmt.Errorf("%s is not between the start (%s) and %s", at.Format(time.DateTime), start.Local().Format(time.DateTime), end.Local().Format(time.DateTime))
+	}
+	return at, nil
+}
+
+// Stops the running time entry at the time. The rest is recorded as the task
+// if stopping, otherwise the task keeps running from the time.
+func splitRunningEntry(store *libStore.Store, current *libStore.CurrentTimeEntry, at, end time.Time, task string, stopping bool) error {
+	if !stopping {
+		stopped := timeentry.Stop(store, current, at)
+		rest := *stopped
+		rest.Task = task
+		continued, err := timeentry.Continue(store, &rest, at)
+		if err != nil {
+			return err
+		}
+		pterm.Success.Printfln("Stopped %s / %s at %s, %s / %s is running since then",
+			stopped.Project, stopped.Task, at.Format(time.DateTime), continued.Project, continued.Task)
+		return nil
+	}
+
+	stopped := timeentry.Stop(store, current, end)
+	first, second, err := timeentry.Split(store, stopped, at, task)
+	if err != nil {
+		return err
+	}
+	printTimeEntries([]*libStore.TimeEntry{first, second})
+	return nil
+}
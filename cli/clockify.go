@@ -3,12 +3,12 @@ package main
 import (
 	"context"
 	"fmt"
+	"os"
 	"time"
 
+	"github.com/gyurkovicsferi/time-tracker/lib/apiclient"
 	"github.com/gyurkovicsferi/time-tracker/lib/clockify"
 	"github.com/gyurkovicsferi/time-tracker/lib/db"
-	libStore "github.com/gyurkovicsferi/time-tracker/lib/store"
-	"github.com/ostafen/clover/v2/query"
 	"github.com/pterm/pterm"
 	"github.com/urfave/cli/v3"
 )
@@ -35,30 +35,70 @@ var ClockifyCmd = &cli.Command{
 						if err != nil {
 							return err
 						}
-						pterm.Println("API Key: " + config.APIKey)
+						pterm.Println("API Key: " + describeSecret(clockify.APIKeySources(config)))
 						pterm.Println("Workspace ID: " + config.WorkspaceID)
+						if config.BaseURL != "" && config.BaseURL != clockify.DefaultBaseURL {
+							pterm.Println("Base URL: " + config.BaseURL)
+						}
 						return nil
 					},
 				},
 				{
 					Name:        "set",
 					Usage:       "set",
-					Description: "Set the Clockify configuration",
+					Description: "Set the Clockify configuration. The API key can be omitted when --api-key-file is given or CLOCKIFY_API_KEY is set.",
+					ArgsUsage:   "[api-key] <workspace-id>",
+					Flags: []cli.Flag{
+						&cli.StringFlag{
+							Name:  "base-url",
+							Usage: "Clockify API base URL",
+							Value: clockify.DefaultBaseURL,
+						},
+						&cli.StringFlag{
+							Name:  "api-key-file",
+							Usage: "Read the API key from this file (must be chmod 600) instead of storing it",
+						},
+						&cli.BoolFlag{
+							Name:  "encrypt",
+							Usage: "Encrypt the stored API key with a passphrase",
+						},
+					},
 					Action: func(ctx context.Context, cmd *cli.Command) error {
-						if cmd.Args().Len() != 2 {
+						config := &clockify.ClockifyConfig{
+							BaseURL:    cmd.String("base-url"),
+							APIKeyFile: cmd.String("api-key-file"),
+						}
+
+						var apiKey string
+						switch cmd.Args().Len() {
+						case 2:
+							apiKey = cmd.Args().Get(0)
+							config.WorkspaceID = cmd.Args().Get(1)
+						case 1:
+							if config.APIKeyFile == "" && os.Getenv(clockify.APIKeyEnv) == "" {
+								return fmt.Errorf("API key is required")
+							}
+							config.WorkspaceID = cmd.Args().Get(0)
+						default:
 							return fmt.Errorf("API key and workspace ID are required")
 						}
-						apiKey := cmd.Args().Get(0)
-						workspaceId := cmd.Args().Get(1)
+
+						if config.APIKeyFile != "" && apiKey != "" {
+							return fmt.Errorf("either pass the API key or --api-key-file, not both")
+						}
+						if err := setAPIKey(config, apiKey, cmd.Bool("encrypt")); err != nil {
+							return err
+						}
+
 						store := clockify.NewClockifyStore(db.NewDB())
-						store.InsertClockifyConfig(&clockify.ClockifyConfig{
-							APIKey:      apiKey,
-							WorkspaceID: workspaceId,
-						})
+						if err := store.InsertClockifyConfig(config); err != nil {
+							return err
+						}
 						pterm.Println("Clockify API key and workspace ID saved")
 						return nil
 					},
 				},
+				newAutoSyncCmd(),
 				{
 					Name:        "delete",
 					Usage:       "delete",
@@ -72,6 +112,18 @@ var ClockifyCmd = &cli.Command{
 				},
 			},
 		},
+		clockifyLoginCmd,
+		{
+			Name:        "flush",
+			Usage:       "flush",
+			Description: "Upload the queued time entry changes to Clockify",
+			Action: func(ctx context.Context, cmd *cli.Command) error {
+				newDb := db.NewDB()
+				defer newDb.Close()
+
+				return flushOutbox(ctx, newDb, promptPassphrase, true, clockify.ProviderName)
+			},
+		},
 		{
 			Name:        "upload-last-week",
 			Usage:       "upload-last-week",
@@ -82,7 +134,7 @@ var ClockifyCmd = &cli.Command{
 				startOfWeek := startOfWeek(lastWeek)
 				endOfWeek := endOfWeek(lastWeek)
 
-				return uploadTimeEntry(startOfWeek, endOfWeek)
+				return pushTimeEntries(ctx, clockify.ProviderName, startOfWeek, endOfWeek)
 			},
 		},
 		{
@@ -93,7 +145,21 @@ var ClockifyCmd = &cli.Command{
 				now := time.Now()
 				startOfDay := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
 				endOfDay := startOfDay.AddDate(0, 0, 1)
-				return uploadTimeEntry(startOfDay, endOfDay)
+				return pushTimeEntries(ctx, clockify.ProviderName, startOfDay, endOfDay)
+			},
+		},
+		{
+			Name:        "pull",
+			Usage:       "pull --from <date> --to <date>",
+			Description: "Import time entries from Clockify",
+			Flags:       dateRangeFlags(),
+			Action: func(ctx context.Context, cmd *cli.Command) error {
+				from, to, err := dateRange(cmd)
+				if err != nil {
+					return err
+				}
+
+				return pullTimeEntries(ctx, clockify.ProviderName, from, to)
 			},
 		},
 	},
@@ -115,46 +181,28 @@ func endOfWeek(t time.Time) time.Time {
 	return time.Date(year, month, day, 23, 59, 59, 0, t.Location())
 }
 
-func uploadTimeEntry(start, end time.Time) error {
-	newDb := db.NewDB()
-	defer newDb.Close()
-
-	store := libStore.NewStore(newDb)
-	defer store.Close()
-
-	timeEntries := store.GetTimeEntriesQuery(func(q *query.Query) *query.Query {
-		return q.Where(query.Field("start").GtEq(start).And(query.Field("start").LtEq(end)))
-	})
-
-	for _, timeEntry := range timeEntries {
-		pterm.Println(timeEntry.ID)
+// Sets the API key on the config the way it should be stored: not at all when
+// it is read from a file, encrypted, or in plaintext as a last resort
+func setAPIKey(config *clockify.ClockifyConfig, apiKey string, encrypt bool) error {
+	if config.APIKeyFile != "" {
+		_, err := apiclient.ReadSecretFile(config.APIKeyFile)
+		return err
 	}
 
-	clockifyStore := clockify.NewClockifyStore(newDb)
-	clockifyConfig, err := clockifyStore.GetClockifyConfig()
-	if err != nil {
-		return err
+	if apiKey == "" {
+		return nil
 	}
 
-	for _, timeEntry := range timeEntries {
-		clockifyTimeEntry, err := clockifyStore.GetClockifyTimeEntry(timeEntry)
+	if encrypt {
+		passphrase, err := newPassphrase()
 		if err != nil {
 			return err
 		}
-		api := clockify.NewClockifyAPI(clockifyConfig.APIKey, clockifyConfig.WorkspaceID)
-		if clockifyTimeEntry != nil {
-			// TODO: update the time entry
-			if clockifyTimeEntry.Deleted {
-				// TODO: delete the time entry
-				pterm.Println("Deleting time entry: " + timeEntry.ID)
-			} else {
-				pterm.Println("Updating time entry: " + timeEntry.ID)
-			}
-		} else {
-			pterm.Println("Creating time entry: " + timeEntry.Project + " " + timeEntry.Task)
-			api.PostNewTimeEntry(timeEntry)
-		}
+		config.EncryptedAPIKey, err = apiclient.EncryptSecret(apiKey, passphrase)
+		return err
 	}
 
+	config.APIKey = apiKey
+	pterm.Warning.Println("The API key is stored in plaintext, use --encrypt or --api-key-file to avoid it")
 	return nil
 }
@@ -0,0 +1,181 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/gyurkovicsferi/time-tracker/lib/apiclient"
+	"github.com/gyurkovicsferi/time-tracker/lib/clockify"
+	"github.com/gyurkovicsferi/time-tracker/lib/db"
+	"github.com/pterm/pterm"
+	"github.com/urfave/cli/v3"
+)
+
+const noDefaultProject = "(no default project)"
+
+var clockifyLoginCmd = &cli.Command{
+	Name:        "login",
+	Usage:       "login",
+	Description: "Validate a Clockify API key and select the workspace and default project",
+	Flags: []cli.Flag{
+		&cli.StringFlag{
+			Name:  "base-url",
+			Usage: "Clockify API base URL",
+			Value: clockify.DefaultBaseURL,
+		},
+		&cli.StringFlag{
+			Name:  "api-key-file",
+			Usage: "Read the API key from this file (must be chmod 600) instead of storing it",
+		},
+		&cli.BoolFlag{
+			Name:  "encrypt",
+			Usage: "Encrypt the stored API key with a passphrase",
+		},
+	},
+	Action: func(ctx context.Context, cmd *cli.Command) error {
+		config := &clockify.ClockifyConfig{
+			BaseURL:    cmd.String("base-url"),
+			APIKeyFile: cmd.String("api-key-file"),
+		}
+
+		apiKey, fromEnv, err := loginAPIKey(config)
+		if err != nil {
+			return err
+		}
+
+		api := clockify.NewClockifyAPI(apiKey, "", apiclient.WithBaseURL(config.BaseURL))
+		user, err := api.GetCurrentUser(ctx)
+		if apiclient.IsStatus(err, http.StatusUnauthorized) || apiclient.IsStatus(err, http.StatusForbidden) {
+			return fmt.Errorf("invalid API key: Clockify rejected it, generate a new one in the Clockify profile settings")
+		}
+		if err != nil {
+			return err
+		}
+		config.UserID = user.ID
+		pterm.Success.Println("Logged in as " + user.Name + " (" + user.Email + ")")
+
+		workspace, err := selectWorkspace(ctx, api, user)
+		if err != nil {
+			return err
+		}
+		config.WorkspaceID = workspace.ID
+
+		api = clockify.NewClockifyAPI(apiKey, workspace.ID, apiclient.WithBaseURL(config.BaseURL))
+		project, err := selectDefaultProject(ctx, api)
+		if err != nil {
+			return err
+		}
+		if project != nil {
+			config.DefaultProjectID = project.ID
+		}
+
+		// A key from the environment keeps being read from there
+		if fromEnv {
+			pterm.Info.Println("The API key is read from " + clockify.APIKeyEnv + ", it is not stored")
+		} else if err := setAPIKey(config, apiKey, cmd.Bool("encrypt")); err != nil {
+			return err
+		}
+
+		store := clockify.NewClockifyStore(db.NewDB())
+		if err := store.InsertClockifyConfig(config); err != nil {
+			return err
+		}
+
+		pterm.Println("Clockify configuration saved for workspace " + workspace.Name)
+		return nil
+	},
+}
+
+// Returns the API key to log in with and whether it is from the environment
+func loginAPIKey(config *clockify.ClockifyConfig) (string, bool, error) {
+	if config.APIKeyFile != "" {
+		apiKey, err := apiclient.ReadSecretFile(config.APIKeyFile)
+		return apiKey, false, err
+	}
+
+	if apiKey := strings.TrimSpace(os.Getenv(clockify.APIKeyEnv)); apiKey != "" {
+		return apiKey, true, nil
+	}
+
+	apiKey, err := pterm.DefaultInteractiveTextInput.WithMask("*").Show("Clockify API key")
+	if err != nil {
+		return "", false, err
+	}
+	if apiKey == "" {
+		return "", false, fmt.Errorf("API key is required")
+	}
+
+	return apiKey, false, nil
+}
+
+func selectWorkspace(ctx context.Context, api *clockify.ClockifyAPI, user *clockify.ClockifyUser) (*clockify.ClockifyWorkspace, error) {
+	workspaces, err := api.GetWorkspaces(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(workspaces) == 0 {
+		return nil, fmt.Errorf("the Clockify user has no workspaces")
+	}
+	if len(workspaces) == 1 {
+		return workspaces[0], nil
+	}
+
+	options := make([]string, len(workspaces))
+	workspacesByOption := make(map[string]*clockify.ClockifyWorkspace)
+	defaultOption := ""
+	for i, workspace := range workspaces {
+		options[i] = fmt.Sprintf("%s (%s)", workspace.Name, workspace.ID)
+		workspacesByOption[options[i]] = workspace
+		if workspace.ID == user.ActiveWorkspace {
+			defaultOption = options[i]
+		}
+	}
+
+	selected, err := pterm.DefaultInteractiveSelect.
+		WithOptions(options).
+		WithDefaultOption(defaultOption).
+		WithDefaultText("Select a workspace").
+		Show()
+	if err != nil {
+		return nil, err
+	}
+
+	return workspacesByOption[selected], nil
+}
+
+// Returns nil when no default project is selected
+func selectDefaultProject(ctx context.Context, api *clockify.ClockifyAPI) (*clockify.ClockifyProject, error) {
+	projects, err := api.GetProjects(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(projects) == 0 {
+		return nil, nil
+	}
+
+	options := []string{noDefaultProject}
+	projectsByOption := make(map[string]*clockify.ClockifyProject)
+	for _, project := range projects {
+		option := project.Name
+		if project.ClientName != "" {
+			option = fmt.Sprintf("%s (%s)", project.Name, project.ClientName)
+		}
+		options = append(options, option)
+		projectsByOption[option] = project
+	}
+
+	selected, err := pterm.DefaultInteractiveSelect.
+		WithOptions(options).
+		WithDefaultText("Select the default project for uploaded time entries").
+		Show()
+	if err != nil {
+		return nil, err
+	}
+
+	return projectsByOption[selected], nil
+}
@@ -0,0 +1,131 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/gyurkovicsferi/time-tracker/lib/db"
+	libStore "github.com/gyurkovicsferi/time-tracker/lib/store"
+	"github.com/pterm/pterm"
+	"github.com/urfave/cli/v3"
+)
+
+var weekdayNames = map[string]time.Weekday{
+	"mon": time.Monday,
+	"tue": time.Tuesday,
+	"wed": time.Wednesday,
+	"thu": time.Thursday,
+	"fri": time.Friday,
+	"sat": time.Saturday,
+	"sun": time.Sunday,
+}
+
+var ConfigCmd = &cli.Command{
+	Name:        "config",
+	Usage:       "Show and change the settings",
+	Description: "The working hours are used to find the untracked time with gaps. The maximum entry length and the idle threshold are used to find the time entries left running.",
+	Category:    "data",
+	Commands: []*cli.Command{
+		{
+			Name:  "show",
+			Usage: "Show the settings",
+			Action: func(ctx context.Context, cmd *cli.Command) error {
+				store := libStore.NewStore(db.NewDB())
+				defer store.Close()
+
+				printSettings(store.GetSettings())
+				return nil
+			},
+		},
+		{
+			Name:        "set",
+			Usage:       "Change the settings",
+			Description: "Change the settings given as flags, the others are kept",
+			Flags: []cli.Flag{
+				&cli.StringFlag{
+					Name:  "work-start",
+					Usage: "Start of the working hours, e.g. 09:00",
+				},
+				&cli.StringFlag{
+					Name:  "work-end",
+					Usage: "End of the working hours, e.g. 17:00",
+				},
+				&cli.StringFlag{
+					Name:  "work-days",
+					Usage: "Working days, e.g. mon,tue,wed,thu,fri",
+				},
+				&cli.StringFlag{
+					Name:  "max-entry-length",
+					Usage: "Time entries longer than this are reported, e.g. 10h, 0 to disable",
+				},
+				&cli.StringFlag{
+					Name:  "idle-threshold",
+					Usage: "Time entries running longer than this after the working hours are reported, e.g. 2h, 0 to disable",
+				},
+			},
+			Action: func(ctx context.Context, cmd *cli.Command) error {
+				store := libStore.NewStore(db.NewDB())
+				defer store.Close()
+
+				settings := store.GetSettings()
+				if HasFlag(cmd, "work-start") {
+					settings.WorkStart = cmd.String("work-start")
+				}
+				if HasFlag(cmd, "work-end") {
+					settings.WorkEnd = cmd.String("work-end")
+				}
+				if HasFlag(cmd, "work-days") {
+					workDays, err := parseWeekdays(cmd.String("work-days"))
+					if err != nil {
+						return err
+					}
+					settings.WorkDays = workDays
+				}
+				if HasFlag(cmd, "max-entry-length") {
+					settings.MaxEntryLength = cmd.String("max-entry-length")
+				}
+				if HasFlag(cmd, "idle-threshold") {
+					settings.IdleThreshold = cmd.String("idle-threshold")
+				}
+
+				if err := settings.Validate(); err != nil {
+					return err
+				}
+
+				store.SaveSettings(settings)
+				printSettings(settings)
+				return nil
+			},
+		},
+	},
+}
+
+func parseWeekdays(value string) ([]time.Weekday, error) {
+	weekdays := make([]time.Weekday, 0)
+	for _, name := range strings.Split(value, ",") {
+		weekday, ok := weekdayNames[strings.ToLower(strings.TrimSpace(name))]
+		if !ok {
+			return nil, fmt.Errorf("invalid day %q, expected one of mon, tue, wed, thu, fri, sat, sun", name)
+		}
+		weekdays = append(weekdays, weekday)
+	}
+	return weekdays, nil
+}
+
+func printSettings(settings *libStore.Settings) {
+	days := make([]string, len(settings.WorkDays))
+	for i, day := range settings.WorkDays {
+		days[i] = day.String()[:3]
+	}
+
+	pterm.DefaultTable.WithHasHeader().WithData(pterm.TableData{
+		{"Setting", "Value"},
+		{"work-start", settings.WorkStart},
+		{"work-end", settings.WorkEnd},
+		{"work-days", strings.ToLower(strings.Join(days, ","))},
+		{"max-entry-length", settings.MaxEntryLength},
+		{"idle-threshold", settings.IdleThreshold},
+	}).Render()
+}
@@ -0,0 +1,125 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	timeentry "github.com/gyurkovicsferi/time-tracker/lib"
+	"github.com/gyurkovicsferi/time-tracker/lib/db"
+	libStore "github.com/gyurkovicsferi/time-tracker/lib/store"
+	"github.com/ostafen/clover/v2/query"
+	"github.com/pterm/pterm"
+	"github.com/urfave/cli/v3"
+)
+
+var ContinueCmd = &cli.Command{
+	Name:        "continue",
+	Aliases:     []string{"c"},
+	Usage:       "Start a new time entry like a previous one",
+	Description: "Start a new time entry with the project, task, tags and note of the time entry with the id, or of the last one. With --select the project and task can be picked from the recent ones.",
+	ArgsUsage:   "[id]",
+	Category:    "time-entry",
+	Flags: []cli.Flag{
+		&cli.BoolFlag{
+			Name:  "select",
+			Usage: "Select the project and task from the recent time entries",
+		},
+		&cli.StringFlag{
+			Name:  "from",
+			Usage: "Start, e.g. 09:30 (today) or 2006-01-02 09:30. The running time entry is stopped then.",
+		},
+	},
+	Action: func(ctx context.Context, cmd *cli.Command) error {
+		db := db.NewDB()
+
+		store := libStore.NewStore(db)
+		defer store.Close()
+
+		from := libStore.StartOfMinute(time.Now())
+		if HasFlag(cmd, "from") {
+			var err error
+			from, err = parseTime(cmd.String("from"), time.Now())
+			if err != nil {
+				return err
+			}
+		}
+
+		var timeEntry *libStore.TimeEntry
+		switch {
+		case cmd.Args().Len() > 0:
+			timeEntry = store.GetTimeEntry(cmd.Args().First())
+			if timeEntry == nil {
+				return fmt.Errorf("time entry %s not found", cmd.Args().First())
+			}
+		case cmd.Bool("select"):
+			var err error
+			timeEntry, err = selectRecentTask(store)
+			if err != nil {
+				return err
+			}
+		default:
+			timeEntries := store.GetTimeEntriesQuery(func(q *query.Query) *query.Query {
+				return q.Limit(1).Sort(query.SortOption{Field: "start", Direction: -1})
+			})
+			if len(timeEntries) > 0 {
+				timeEntry = timeEntries[0]
+			}
+		}
+
+		if timeEntry == nil {
+			pterm.Info.Println("No time entry to continue")
+			return nil
+		}
+
+		current, err := timeentry.Continue(store, timeEntry, from)
+		if err != nil {
+			return err
+		}
+
+		pterm.NewStyle(pterm.FgGreen).Println("Started time entry: ", current.Project, " - ", current.Task, " at ", from.Format("15:04:05"))
+		return nil
+	},
+}
+
+// Lets the user pick a project and task of the recent time entries, the
+// most recent time entry of the pair is returned
+func selectRecentTask(store *libStore.Store) (*libStore.TimeEntry, error) {
+	timeEntries := recentTasks(store)
+	if len(timeEntries) == 0 {
+		return nil, nil
+	}
+
+	options := make([]string, len(timeEntries))
+	byOption := make(map[string]*libStore.TimeEntry)
+	for i, timeEntry := range timeEntries {
+		options[i] = timeEntry.Project + " / " + timeEntry.Task
+		byOption[options[i]] = timeEntry
+	}
+
+	selected, err := pterm.DefaultInteractiveSelect.WithOptions(options).WithDefaultText("Select the task to continue").Show()
+	if err != nil {
+		return nil, err
+	}
+	return byOption[selected], nil
+}
+
+// Returns the most recent time entry of each project and task of the last 100
+// time entries, the most recent first
+func recentTasks(store *libStore.Store) []*libStore.TimeEntry {
+	timeEntries := store.GetTimeEntriesQuery(func(q *query.Query) *query.Query {
+		return q.Limit(100).Sort(query.SortOption{Field: "start", Direction: -1})
+	})
+
+	recent := make([]*libStore.TimeEntry, 0)
+	seen := make(map[[2]string]bool)
+	for _, timeEntry := range timeEntries {
+		key := [2]string{timeEntry.Project, timeEntry.Task}
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		recent = append(recent, timeEntry)
+	}
+	return recent
+}
@@ -3,48 +3,215 @@ package main
 import (
 	"context"
 	"fmt"
+	"slices"
+	"time"
 
-	"github.com/gyurkovicsferi/time-tracker/lib/clockify"
+	timeentry "github.com/gyurkovicsferi/time-tracker/lib"
 	"github.com/gyurkovicsferi/time-tracker/lib/db"
-	store "github.com/gyurkovicsferi/time-tracker/lib/store"
+	"github.com/gyurkovicsferi/time-tracker/lib/provider"
+	libStore "github.com/gyurkovicsferi/time-tracker/lib/store"
 	"github.com/ostafen/clover/v2/query"
+	"github.com/pterm/pterm"
 	"github.com/urfave/cli/v3"
 )
 
 var DeleteCmd = &cli.Command{
-	Name:      "delete",
-	Usage:     "Delete a time entry",
-	ArgsUsage: "[id]",
-	Category:  "time-entry",
-	Flags: []cli.Flag{
+	Name:        "delete",
+	Usage:       "Delete time entries, moving them to the trash",
+	Description: "Delete the time entries with the ids, the last one, or the ones matching the filters. Without any of them the time entries to delete can be selected from the recent ones.",
+	ArgsUsage:   "[id]...",
+	Category:    "time-entry",
+	Flags: append(filterFlags(),
 		&cli.BoolFlag{
 			Name:  "last",
 			Usage: "Delete the last time entry",
 		},
-	},
+		&cli.BoolFlag{
+			Name:  "select",
+			Usage: "Select the time entries to delete from the ones matching the filters",
+		},
+		&cli.BoolFlag{
+			Name:    "yes",
+			Aliases: []string{"y"},
+			Usage:   "Delete without asking for confirmation",
+		},
+	),
 	Action: func(ctx context.Context, cmd *cli.Command) error {
 		db := db.NewDB()
 
-		store := store.NewStore(db)
+		store := libStore.NewStore(db)
 		defer store.Close()
 
-		if cmd.Bool("last") {
-			store.DeleteTimeEntry(store.GetTimeEntriesQuery(func(q *query.Query) *query.Query {
+		var timeEntries []*libStore.TimeEntry
+		switch {
+		case cmd.Args().Len() > 0:
+			for _, id := range cmd.Args().Slice() {
+				if slices.ContainsFunc(timeEntries, func(timeEntry *libStore.TimeEntry) bool { return timeEntry.ID == id }) {
+					continue
+				}
+				timeEntry := store.GetTimeEntry(id)
+				if timeEntry == nil {
+					return fmt.Errorf("time entry %s not found", id)
+				}
+				timeEntries = append(timeEntries, timeEntry)
+			}
+		case cmd.Bool("last"):
+			timeEntries = store.GetTimeEntriesQuery(func(q *query.Query) *query.Query {
 				return q.Limit(1).Sort(query.SortOption{
 					Field:     "start",
 					Direction: -1,
 				})
-			})[0].ID)
-		} else {
-			if cmd.Args().First() == "" {
-				return fmt.Errorf("id is required")
+			})
+		default:
+			filter, filtered, err := timeEntryFilter(cmd)
+			if err != nil {
+				return err
+			}
+
+			timeEntries = store.GetTimeEntriesQuery(filter)
+			if !filtered || cmd.Bool("select") {
+				timeEntries, err = selectTimeEntries(timeEntries, "Select the time entries to delete")
+				if err != nil {
+					return err
+				}
+			}
+		}
+
+		if len(timeEntries) == 0 {
+			pterm.Info.Println("No time entries to delete")
+			return nil
+		}
+
+		printTimeEntries(timeEntries)
+		if !cmd.Bool("yes") {
+			confirmed, err := pterm.DefaultInteractiveConfirm.Show(fmt.Sprintf("Delete %d time entries?", len(timeEntries)))
+			if err != nil {
+				return err
+			}
+			if !confirmed {
+				return nil
 			}
-			store.DeleteTimeEntry(cmd.Args().First())
 		}
 
-		clockifyStore := clockify.NewClockifyStore(db)
-		clockifyStore.MakeClockifyTimeEntryDeleted(cmd.Args().First())
+		ids := make([]string, len(timeEntries))
+		for i, timeEntry := range timeEntries {
+			ids[i] = timeEntry.ID
+		}
+		timeentry.DeleteMany(store, ids)
+
+		ledger := provider.NewLedgerStore(db)
+		for _, id := range ids {
+			if err := ledger.MarkDeleted(id); err != nil {
+				return err
+			}
+		}
 
+		pterm.Success.Printfln("Deleted %d time entries, restore them with trash restore or undo", len(ids))
 		return nil
 	},
 }
+
+// Flags selecting time entries by date, project and task
+func filterFlags() []cli.Flag {
+	return []cli.Flag{
+		&cli.TimestampFlag{
+			Name:  "from",
+			Usage: "From date",
+			Config: cli.TimestampConfig{
+				Timezone: time.Local,
+				Layouts:  []string{"2006-01-02"},
+			},
+		},
+		&cli.TimestampFlag{
+			Name:  "to",
+			Usage: "To date (inclusive)",
+			Config: cli.TimestampConfig{
+				Timezone: time.Local,
+				Layouts:  []string{"2006-01-02"},
+			},
+		},
+		&cli.StringFlag{
+			Name:  "project",
+			Usage: "Only the time entries of the project",
+		},
+		&cli.StringFlag{
+			Name:  "task",
+			Usage: "Only the time entries of the task",
+		},
+	}
+}
+
+// Returns the query of the filter flags, sorted by start, and whether any of
+// them was given. Without filters the last 100 time entries are returned.
+func timeEntryFilter(cmd *cli.Command) (func(*query.Query) *query.Query, bool, error) {
+	criteria := make([]query.Criteria, 0)
+	if HasFlag(cmd, "from") {
+		criteria = append(criteria, query.Field("start").GtEq(libStore.StartOfDay(cmd.Timestamp("from"))))
+	}
+	if HasFlag(cmd, "to") {
+		criteria = append(criteria, query.Field("start").LtEq(libStore.EndOfDay(cmd.Timestamp("to"))))
+	}
+	if HasFlag(cmd, "from") && HasFlag(cmd, "to") && cmd.Timestamp("to").Before(cmd.Timestamp("from")) {
+		return nil, false, fmt.Errorf("--to is before --from")
+	}
+	if HasFlag(cmd, "project") {
+		criteria = append(criteria, query.Field("project").Eq(cmd.String("project")))
+	}
+	if HasFlag(cmd, "task") {
+		criteria = append(criteria, query.Field("task").Eq(cmd.String("task")))
+	}
+
+	if len(criteria) == 0 {
+		return func(q *query.Query) *query.Query {
+			return q.Limit(100).Sort(query.SortOption{Field: "start", Direction: -1})
+		}, false, nil
+	}
+
+	return func(q *query.Query) *query.Query {
+		where := criteria[0]
+		for _, c := range criteria[1:] {
+			where = where.And(c)
+		}
+		return q.Where(where).Sort(query.SortOption{Field: "start", Direction: 1})
+	}, true, nil
+}
+
+// Lets the user pick some of the time entries
+func selectTimeEntries(timeEntries []*libStore.TimeEntry, text string) ([]*libStore.TimeEntry, error) {
+	if len(timeEntries) == 0 {
+		return nil, nil
+	}
+
+	options := make([]string, len(timeEntries))
+	byOption := make(map[string]*libStore.TimeEntry)
+	for i, timeEntry := range timeEntries {
+		options[i] = fmt.Sprintf("%s | %s | %s | %s | %s", timeEntry.ID, timeEntry.Project, timeEntry.Task, timeEntry.Start.Format(time.RFC850), timeEntry.End.Format(time.RFC850))
+		byOption[options[i]] = timeEntry
+	}
+
+	selected, err := pterm.DefaultInteractiveMultiselect.WithOptions(options).WithDefaultText(text).Show()
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]*libStore.TimeEntry, len(selected))
+	for i, option := range selected {
+		result[i] = byOption[option]
+	}
+	return result, nil
+}
+
+func printTimeEntries(timeEntries []*libStore.TimeEntry) {
+	table := pterm.TableData{{"ID", "Project", "Task", "Start", "End", "Duration"}}
+	for _, timeEntry := range timeEntries {
+		table = append(table, []string{
+			timeEntry.ID,
+			timeEntry.Project,
+			timeEntry.Task,
+			timeEntry.Start.Local().Format(time.DateTime),
+			timeEntry.End.Local().Format(time.DateTime),
+			formatDuration(timeEntry.End.Sub(timeEntry.Start)),
+		})
+	}
+	pterm.DefaultTable.WithHasHeader().WithData(table).Render()
+}
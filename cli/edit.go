@@ -4,7 +4,7 @@ import (
 	"context"
 	"fmt"
 	"os"
-	"os/exec"
+	"slices"
 	"strings"
 	"time"
 
@@ -12,179 +12,306 @@ import (
 	"github.com/pterm/pterm"
 	"github.com/urfave/cli/v3"
 
+	timeentry "github.com/gyurkovicsferi/time-tracker/lib"
 	"github.com/gyurkovicsferi/time-tracker/lib/db"
+	"github.com/gyurkovicsferi/time-tracker/lib/editfile"
 	libStore "github.com/gyurkovicsferi/time-tracker/lib/store"
 )
 
 var EditCmd = &cli.Command{
-	Name:     "edit",
-	Usage:    "Edit a time entry",
-	Category: "time-entry",
+	Name:        "edit",
+	Usage:       "Edit a time entry",
+	Description: "Edit the time entry with the id, the last one, or one selected from the recent ones. The changes are given in flags or, without them, in $EDITOR. The running time entry can be edited too, except for its end. With --from or --to all the time entries of the days are edited in $EDITOR, an emptied document changes nothing and deleting entries asks for confirmation unless --yes is given.",
+	ArgsUsage:   "[id]",
+	Category:    "time-entry",
+	Flags: []cli.Flag{
+		&cli.BoolFlag{
+			Name:  "last",
+			Usage: "Edit the last time entry",
+		},
+		&cli.BoolFlag{
+			Name:  "running",
+			Usage: "Edit the running time entry",
+		},
+		&cli.TimestampFlag{
+			Name:  "from",
+			Usage: "Edit all the time entries from the date",
+			Config: cli.TimestampConfig{
+				Timezone: time.Local,
+				Layouts:  []string{"2006-01-02"},
+			},
+		},
+		&cli.TimestampFlag{
+			Name:  "to",
+			Usage: "Edit all the time entries to the date (inclusive)",
+			Config: cli.TimestampConfig{
+				Timezone: time.Local,
+				Layouts:  []string{"2006-01-02"},
+			},
+		},
+		&cli.BoolFlag{
+			Name:    "yes",
+			Aliases: []string{"y"},
+			Usage:   "Delete the time entries removed in the bulk edit without confirmation",
+		},
+		&cli.StringFlag{
+			Name:  "project",
+			Usage: "New project",
+		},
+		&cli.StringFlag{
+			Name:  "task",
+			Usage: "New task",
+		},
+		&cli.StringFlag{
+			Name:  "start",
+			Usage: "New start, e.g. 09:30 (on the day of the entry) or 2006-01-02 09:30",
+		},
+		&cli.StringFlag{
+			Name:  "end",
+			Usage: "New end, e.g. 17:00 (on the day of the entry) or 2006-01-02 17:00",
+		},
+		&cli.StringFlag{
+			Name:  "note",
+			Usage: "New note",
+		},
+	},
 	Action: func(ctx context.Context, cmd *cli.Command) error {
 		db := db.NewDB()
 
 		store := libStore.NewStore(db)
 		defer store.Close()
 
-		entries := store.GetTimeEntriesQuery(func(q *query.Query) *query.Query {
-			return q.Limit(100).Sort(query.SortOption{
-				Field:     "start",
-				Direction: -1,
-			})
-		})
-
-		entriesString := make([]string, len(entries))
-		entiresByString := make(map[string]*libStore.TimeEntry)
-		for i, entry := range entries {
-			entriesString[i] = fmt.Sprintf("%s | %s | %s | %s | %s", entry.ID, entry.Project, entry.Task, entry.Start.Format(time.RFC850), entry.End.Format(time.RFC850))
-			entiresByString[entriesString[i]] = entry
+		if HasFlag(cmd, "from") || HasFlag(cmd, "to") {
+			return bulkEdit(cmd, db, store)
 		}
 
-		selected, err := pterm.DefaultInteractiveSelect.WithOptions(entriesString).WithDefaultText("Select a time entry").Show()
+		selectedEntry, current, err := selectTimeEntryToEdit(cmd, store)
 		if err != nil {
 			return err
 		}
-
-		selectedEntry := entiresByString[selected]
-
-		tempFile, err := createTempFileToEdit(selectedEntry)
-		if err != nil {
-			return err
+		if current != nil {
+			if HasFlag(cmd, "end") {
+				return fmt.Errorf("the running time entry has no end, use stop --end to end it")
+			}
+			return amendRunning(cmd, store, current, "start")
 		}
 
-		editor := os.Getenv("EDITOR")
-		if editor == "" {
-			editor = "vim"
+		var editedEntry *libStore.TimeEntry
+		if slices.ContainsFunc([]string{"project", "task", "start", "end", "note"}, func(flag string) bool { return HasFlag(cmd, flag) }) {
+			editedEntry, err = editWithFlags(cmd, selectedEntry)
+		} else {
+			editedEntry, err = editInEditor(store, selectedEntry)
 		}
-
-		editorCmd := exec.Command(editor, tempFile)
-		editorCmd.Stdin = os.Stdin
-		editorCmd.Stdout = os.Stdout
-		editorCmd.Stderr = os.Stderr
-		err = editorCmd.Run()
 		if err != nil {
 			return err
 		}
 
-		editedFile, err := os.ReadFile(tempFile)
-		if err != nil {
+		if err := timeentry.Validate(store, editedEntry); err != nil {
 			return err
 		}
 
-		editedFileString := string(editedFile)
+		printChanges(selectedEntry, editedEntry)
+		timeentry.Update(store, editedEntry)
 
-		editedFileStringLines := strings.Split(editedFileString, "\n")
-		newId := findLineWithPrefixAndTrim(editedFileStringLines, "ID:")
-		if newId == "" {
-			newId = selectedEntry.ID
-		}
-		if newId != selectedEntry.ID {
-			return fmt.Errorf("invalid file content, ID is not the same")
-		}
+		return nil
+	},
+}
 
-		newProject := findLineWithPrefixAndTrim(editedFileStringLines, "Project:")
-		if newProject == "" {
-			newProject = selectedEntry.Project
+// The time entry of the id argument, the last one with --last, the running
+// one with --running, or the one selected from the running and the recent
+// time entries. Returns the running time entry if it was selected.
+func selectTimeEntryToEdit(cmd *cli.Command, store *libStore.Store) (*libStore.TimeEntry, *libStore.CurrentTimeEntry, error) {
+	current := store.GetCurrentTimeEntry()
+	if cmd.Bool("running") {
+		if current == nil {
+			return nil, nil, fmt.Errorf("no running time entry")
 		}
+		return nil, current, nil
+	}
 
-		newTask := findLineWithPrefixAndTrim(editedFileStringLines, "Task:")
-		if newTask == "" {
-			newTask = selectedEntry.Task
+	if id := cmd.Args().First(); id != "" {
+		if current != nil && current.ID == id {
+			return nil, current, nil
 		}
-
-		var newStart time.Time
-		newStartRaw := findLineWithPrefixAndTrim(editedFileStringLines, "Start:")
-		if newStartRaw == "" {
-			newStart = selectedEntry.Start
-		} else {
-			newStart, err = time.Parse(time.RFC822, newStartRaw)
-			if err != nil {
-				return err
-			}
+		entry := store.GetTimeEntry(id)
+		if entry == nil {
+			return nil, nil, fmt.Errorf("time entry %s not found", id)
 		}
+		return entry, nil, nil
+	}
 
-		var newEnd time.Time
-		newEndRaw := findLineWithPrefixAndTrim(editedFileStringLines, "End:")
-		if newEndRaw == "" {
-			newEnd = selectedEntry.End
-		} else {
-			newEnd, err = time.Parse(time.RFC822, newEndRaw)
-			if err != nil {
-				return err
-			}
+	entries := store.GetTimeEntriesQuery(func(q *query.Query) *query.Query {
+		return q.Limit(100).Sort(query.SortOption{
+			Field:     "start",
+			Direction: -1,
+		})
+	})
+	if cmd.Bool("last") {
+		if len(entries) == 0 {
+			return nil, nil, fmt.Errorf("no time entries to edit")
 		}
+		return entries[0], nil, nil
+	}
+	if len(entries) == 0 && current == nil {
+		return nil, nil, fmt.Errorf("no time entries to edit")
+	}
 
-		if newEnd.Before(newStart) {
-			return fmt.Errorf("end time is before start time")
-		}
+	entriesString := make([]string, 0, len(entries)+1)
+	entiresByString := make(map[string]*libStore.TimeEntry)
+	runningString := ""
+	if current != nil {
+		runningString = fmt.Sprintf("%s | %s | %s | %s | running", current.ID, current.Project, current.Task, current.Start.Format(time.RFC850))
+		entriesString = append(entriesString, runningString)
+	}
+	for _, entry := range entries {
+		entryString := fmt.Sprintf("%s | %s | %s | %s | %s", entry.ID, entry.Project, entry.Task, entry.Start.Format(time.RFC850), entry.End.Format(time.RFC850))
+		entriesString = append(entriesString, entryString)
+		entiresByString[entryString] = entry
+	}
 
-		if newProject != selectedEntry.Project {
-			pterm.Println(pterm.LightGreen("Project changed from " + pterm.LightRed(selectedEntry.Project) + " to " + pterm.LightRed(newProject)))
-		}
+	selected, err := pterm.DefaultInteractiveSelect.WithOptions(entriesString).WithDefaultText("Select a time entry").Show()
+	if err != nil {
+		return nil, nil, err
+	}
 
-		if newTask != selectedEntry.Task {
-			pterm.Println(pterm.LightGreen("Task changed from " + pterm.LightRed(selectedEntry.Task) + " to " + pterm.LightRed(newTask)))
-		}
+	if current != nil && selected == runningString {
+		return nil, current, nil
+	}
+	return entiresByString[selected], nil, nil
+}
 
-		if newStart != selectedEntry.Start {
-			oldStart := selectedEntry.Start.Format(time.RFC822)
-			pterm.Println(pterm.LightGreen("Start changed from " + pterm.LightRed(oldStart) + " to " + pterm.LightRed(newStartRaw)))
-		}
+func editWithFlags(cmd *cli.Command, selectedEntry *libStore.TimeEntry) (*libStore.TimeEntry, error) {
+	editedEntry := *selectedEntry
 
-		if newEnd != selectedEntry.End {
-			oldEnd := selectedEntry.End.Format(time.RFC822)
-			pterm.Println(pterm.LightGreen("End changed from " + pterm.LightRed(oldEnd) + " to " + pterm.LightRed(newEndRaw)))
+	if HasFlag(cmd, "project") {
+		editedEntry.Project = cmd.String("project")
+		if editedEntry.Project == "" {
+			return nil, fmt.Errorf("project can't be empty")
 		}
+	}
+	if HasFlag(cmd, "task") {
+		editedEntry.Task = cmd.String("task")
+	}
+	if HasFlag(cmd, "note") {
+		editedEntry.Note = cmd.String("note")
+	}
 
-		editedEntry := libStore.TimeEntry{
-			ID:      selectedEntry.ID,
-			Project: newProject,
-			Task:    newTask,
-			Start:   newStart,
-			End:     newEnd,
+	var err error
+	if HasFlag(cmd, "start") {
+		editedEntry.Start, err = parseTime(cmd.String("start"), selectedEntry.Start)
+		if err != nil {
+			return nil, err
 		}
+	}
+	if HasFlag(cmd, "end") {
+		editedEntry.End, err = parseTime(cmd.String("end"), selectedEntry.End)
+		if err != nil {
+			return nil, err
+		}
+	}
 
-		store.UpdateTimeEntry(&editedEntry)
-
-		return nil
-	},
+	return &editedEntry, nil
 }
 
-func createTempFileToEdit(entry *libStore.TimeEntry) (string, error) {
-	tempFile, err := os.CreateTemp("", "time-tracker-entry-*")
+func editInEditor(store *libStore.Store, selectedEntry *libStore.TimeEntry) (*libStore.TimeEntry, error) {
+	content, err := editfile.FormatOne(selectedEntry,
+		"Modify this file to change the time entry, the id can't be changed",
+		"Times are like 2006-01-02T15:04:05+02:00 or 2006-01-02 15:04 (local time)",
+	)
 	if err != nil {
-		return "", err
+		return nil, err
 	}
-	defer tempFile.Close()
 
-	b := strings.Builder{}
+	var editedEntry *libStore.TimeEntry
+	err = editUntilValid(content, func(edited string) error {
+		editedEntry, err = editfile.ParseOne(edited, selectedEntry)
+		if err != nil {
+			return err
+		}
+		return timeentry.Validate(store, editedEntry)
+	})
+	return editedEntry, err
+}
 
-	b.WriteString(fmt.Sprintln("# Modify this file to change the time entry"))
-	b.WriteString(fmt.Sprintln("# Don't change the ID line"))
-	b.WriteString(fmt.Sprintln())
-	b.WriteString(fmt.Sprintln("ID:", entry.ID, "# Don't change this line"))
-	b.WriteString(fmt.Sprintln("Project:", entry.Project))
-	b.WriteString(fmt.Sprintln("Task:", entry.Task))
-	b.WriteString(fmt.Sprintln("Start:", entry.Start.Format(time.RFC822)))
-	b.WriteString(fmt.Sprintln("End:", entry.End.Format(time.RFC822)))
+// Opens the content in $EDITOR until parse accepts the edited content. On
+// errors the user can fix the file, which is reopened with the error at its
+// end, or give up.
+func editUntilValid(content string, parse func(string) error) error {
+	tempFile, err := os.CreateTemp("", "time-tracker-entry-*.yaml")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tempFile.Name())
 
-	str := b.String()
+	for {
+		_, err = tempFile.WriteAt([]byte(content), 0)
+		if err == nil {
+			err = tempFile.Truncate(int64(len(content)))
+		}
+		if err != nil {
+			tempFile.Close()
+			return err
+		}
 
-	tempFile.WriteString(str)
+		if err := runEditor(tempFile.Name()); err != nil {
+			tempFile.Close()
+			return err
+		}
 
-	return tempFile.Name(), nil
-}
+		edited, err := os.ReadFile(tempFile.Name())
+		if err != nil {
+			tempFile.Close()
+			return err
+		}
+
+		parseErr := parse(string(edited))
+		if parseErr == nil {
+			return tempFile.Close()
+		}
 
-func findLineWithPrefixAndTrim(lines []string, prefix string) string {
-	for _, line := range lines {
-		if strings.HasPrefix(line, prefix) {
-			return removeLineComments(strings.TrimSpace(line[len(prefix):]))
+		pterm.Error.Println(parseErr)
+		again, err := pterm.DefaultInteractiveConfirm.WithDefaultValue(true).Show("Edit again?")
+		if err != nil || !again {
+			tempFile.Close()
+			return parseErr
 		}
+
+		content = withEditError(string(edited), parseErr)
 	}
+}
 
-	return ""
+const editErrorPrefix = "# Error: "
+
+// Replaces the error comment at the end of the content, where it doesn't
+// shift the line numbers of the error
+func withEditError(content string, err error) string {
+	lines := strings.Split(strings.TrimRight(content, "\n"), "\n")
+	for len(lines) > 0 && strings.HasPrefix(lines[len(lines)-1], editErrorPrefix) {
+		lines = lines[:len(lines)-1]
+	}
+	return strings.Join(lines, "\n") + "\n" + editErrorPrefix + err.Error() + "\n"
 }
 
-func removeLineComments(line string) string {
-	return strings.TrimSpace(strings.Split(line, "#")[0])
+func printChanges(selectedEntry, editedEntry *libStore.TimeEntry) {
+	if editedEntry.Project != selectedEntry.Project {
+		pterm.Println(pterm.LightGreen("Project changed from " + pterm.LightRed(selectedEntry.Project) + " to " + pterm.LightRed(editedEntry.Project)))
+	}
+
+	if editedEntry.Task != selectedEntry.Task {
+		pterm.Println(pterm.LightGreen("Task changed from " + pterm.LightRed(selectedEntry.Task) + " to " + pterm.LightRed(editedEntry.Task)))
+	}
+
+	if !editedEntry.Start.Equal(selectedEntry.Start) {
+		oldStart := selectedEntry.Start.Format(time.RFC822)
+		pterm.Println(pterm.LightGreen("Start changed from " + pterm.LightRed(oldStart) + " to " + pterm.LightRed(editedEntry.Start.Format(time.RFC822))))
+	}
+
+	if !editedEntry.End.Equal(selectedEntry.End) {
+		oldEnd := selectedEntry.End.Format(time.RFC822)
+		pterm.Println(pterm.LightGreen("End changed from " + pterm.LightRed(oldEnd) + " to " + pterm.LightRed(editedEntry.End.Format(time.RFC822))))
+	}
+
+	if editedEntry.Note != selectedEntry.Note {
+		pterm.Println(pterm.LightGreen("Note changed from " + pterm.LightRed(selectedEntry.Note) + " to " + pterm.LightRed(editedEntry.Note)))
+	}
 }
@@ -0,0 +1,144 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"slices"
+	"time"
+
+	timeentry "github.com/gyurkovicsferi/time-tracker/lib"
+	"github.com/gyurkovicsferi/time-tracker/lib/editfile"
+	"github.com/gyurkovicsferi/time-tracker/lib/provider"
+	libStore "github.com/gyurkovicsferi/time-tracker/lib/store"
+	"github.com/ostafen/clover/v2"
+	"github.com/ostafen/clover/v2/query"
+	"github.com/pterm/pterm"
+	"github.com/urfave/cli/v3"
+)
+
+// Edits the time entries between --from and --to in one editor session
+func bulkEdit(cmd *cli.Command, db *clover.DB, store *libStore.Store) error {
+	from := libStore.StartOfDay(time.Now())
+	to := libStore.EndOfDay(time.Now())
+	if HasFlag(cmd, "from") {
+		from = libStore.StartOfDay(cmd.Timestamp("from"))
+	}
+	if HasFlag(cmd, "to") {
+		to = libStore.EndOfDay(cmd.Timestamp("to"))
+		if !HasFlag(cmd, "from") {
+			from = libStore.StartOfDay(to)
+		}
+	}
+	if to.Before(from) {
+		return fmt.Errorf("--to is before --from")
+	}
+
+	entries := store.GetTimeEntriesQuery(func(q *query.Query) *query.Query {
+		return q.Where(query.Field("start").GtEq(from).And(query.Field("start").LtEq(to))).
+			Sort(query.SortOption{Field: "start", Direction: 1})
+	})
+
+	content, err := editfile.Format(entries,
+		fmt.Sprintf("Time entries from %s to %s", from.Format(time.DateOnly), to.Format(time.DateOnly)),
+		"Change the fields to edit a time entry, remove it from the list to delete it.",
+		"Empty the document to abort.",
+		"Add an entry without id to create a time entry. Don't change the ids.",
+		"Times are like 2006-01-02T15:04:05+02:00 or 2006-01-02 15:04 (local time)",
+	)
+	if err != nil {
+		return err
+	}
+
+	var changes *timeentry.Changes
+	err = editUntilValid(content, func(edited string) error {
+		changes, err = editfile.Parse(edited, entries)
+		if errors.Is(err, editfile.ErrEmpty) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		return timeentry.ValidateChanges(store, changes)
+	})
+	if err != nil {
+		return err
+	}
+
+	if changes == nil {
+		pterm.Info.Println("The document is empty, nothing is changed")
+		return nil
+	}
+	if changes.Empty() {
+		pterm.Info.Println("No changes")
+		return nil
+	}
+
+	if len(changes.Delete) > 0 && !cmd.Bool("yes") {
+		deleted := make([]*libStore.TimeEntry, 0, len(changes.Delete))
+		for _, entry := range entries {
+			if slices.Contains(changes.Delete, entry.ID) {
+				deleted = append(deleted, entry)
+			}
+		}
+
+		printTimeEntries(deleted)
+		confirmed, err := pterm.DefaultInteractiveConfirm.Show(fmt.Sprintf("Delete %d time entries?", len(deleted)))
+		if err != nil {
+			return err
+		}
+		if !confirmed {
+			pterm.Info.Println("Nothing is changed")
+			return nil
+		}
+	}
+
+	if err := timeentry.Apply(store, changes); err != nil {
+		return err
+	}
+
+	ledger := provider.NewLedgerStore(db)
+	for _, id := range changes.Delete {
+		if err := ledger.MarkDeleted(id); err != nil {
+			return err
+		}
+	}
+
+	printBulkChanges(changes, entries)
+	return nil
+}
+
+func runEditor(path string) error {
+	editor := os.Getenv("EDITOR")
+	if editor == "" {
+		editor = "vim"
+	}
+
+	editorCmd := exec.Command(editor, path)
+	editorCmd.Stdin = os.Stdin
+	editorCmd.Stdout = os.Stdout
+	editorCmd.Stderr = os.Stderr
+	return editorCmd.Run()
+}
+
+func printBulkChanges(changes *timeentry.Changes, entries []*libStore.TimeEntry) {
+	original := make(map[string]*libStore.TimeEntry, len(entries))
+	for _, entry := range entries {
+		original[entry.ID] = entry
+	}
+
+	for _, entry := range changes.Update {
+		pterm.Println(pterm.LightGreen("Updated " + entry.ID))
+		printChanges(original[entry.ID], entry)
+	}
+	for _, entry := range changes.Create {
+		pterm.Println(pterm.LightGreen("Created " + entry.Project + " / " + entry.Task + " at " + entry.Start.Format(time.RFC822)))
+	}
+	for _, id := range changes.Delete {
+		entry := original[id]
+		pterm.Println(pterm.LightRed("Deleted " + entry.Project + " / " + entry.Task + " at " + entry.Start.Format(time.RFC822)))
+	}
+
+	pterm.Success.Printfln("%d updated, %d created, %d deleted", len(changes.Update), len(changes.Create), len(changes.Delete))
+}
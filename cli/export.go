@@ -0,0 +1,186 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/gyurkovicsferi/time-tracker/lib/db"
+	"github.com/gyurkovicsferi/time-tracker/lib/ics"
+	libStore "github.com/gyurkovicsferi/time-tracker/lib/store"
+	"github.com/gyurkovicsferi/time-tracker/lib/timewarrior"
+	"github.com/gyurkovicsferi/time-tracker/lib/toggl"
+	"github.com/ostafen/clover/v2/query"
+	"github.com/pterm/pterm"
+	"github.com/urfave/cli/v3"
+)
+
+var ExportCmd = &cli.Command{
+	Name:        "export",
+	Usage:       "Export time entries for other time trackers",
+	Description: "Export the time entries of a period to files other time trackers can import",
+	Category:    "data",
+	Commands: []*cli.Command{
+		{
+			Name:        "toggl",
+			Usage:       "toggl --from <date> --to <date> --out <file.csv|file.json>",
+			Description: "Export to the Toggl Track CSV import format or to JSON",
+			Flags: append(exportFlags(),
+				&cli.StringFlag{
+					Name:  "format",
+					Usage: "csv or json, detected from the --out extension by default (csv for stdout)",
+				},
+				&cli.StringFlag{
+					Name:  "email",
+					Usage: "Email of the Toggl user, required by the Toggl CSV import",
+				},
+			),
+			Action: func(ctx context.Context, cmd *cli.Command) error {
+				timeEntries, err := exportedTimeEntries(cmd)
+				if err != nil {
+					return err
+				}
+
+				format := fileFormat(cmd, cmd.String("out"))
+				if format == "" {
+					format = "csv"
+				}
+
+				return writeExport(cmd, len(timeEntries), func(w io.Writer) error {
+					switch format {
+					case "csv":
+						return toggl.WriteCSV(w, timeEntries, cmd.String("email"))
+					case "json":
+						return toggl.WriteJSON(w, timeEntries)
+					default:
+						return fmt.Errorf("unknown format, use --format csv or --format json")
+					}
+				})
+			},
+		},
+		{
+			Name:        "timewarrior",
+			Usage:       "timewarrior --from <date> --to <date> --out <dir>",
+			Description: "Export to Timewarrior data files, with the project and the task as project: and task: tags and the note as the annotation. The running entry is exported as an open interval if it started in the range.",
+			Flags: append(dateRangeFlags(),
+				&cli.StringFlag{
+					Name:     "out",
+					Aliases:  []string{"o"},
+					Usage:    "Timewarrior database directory, the files are written to its data directory",
+					Required: true,
+				},
+				&cli.BoolFlag{
+					Name:  "overwrite",
+					Usage: "Replace the intervals of the exported dates in the existing data files, the other intervals are kept",
+				},
+			),
+			Action: func(ctx context.Context, cmd *cli.Command) error {
+				from, to, err := dateRange(cmd)
+				if err != nil {
+					return err
+				}
+				timeEntries, err := exportedTimeEntries(cmd)
+				if err != nil {
+					return err
+				}
+
+				intervals := make([]*timewarrior.Interval, 0, len(timeEntries)+1)
+				for _, timeEntry := range timeEntries {
+					intervals = append(intervals, timewarrior.FromTimeEntry(timeEntry))
+				}
+
+				newDb := db.NewDB()
+				current := libStore.NewStore(newDb).GetCurrentTimeEntry()
+				newDb.Close()
+				if current != nil && !current.Start.Before(from) && !current.Start.After(to) {
+					intervals = append(intervals, timewarrior.FromCurrentTimeEntry(current))
+				}
+
+				files, err := timewarrior.WriteDir(cmd.String("out"), intervals, from, to, cmd.Bool("overwrite"))
+				for _, file := range files {
+					pterm.Println("Written " + file)
+				}
+				if err != nil {
+					return err
+				}
+
+				pterm.Success.Printfln("Exported %d intervals", len(intervals))
+				return nil
+			},
+		},
+		{
+			Name:        "ics",
+			Usage:       "ics --from <date> --to <date> --out <file.ics>",
+			Description: "Export as iCalendar events, to overlay the tracked time on a calendar",
+			Flags:       exportFlags(),
+			Action: func(ctx context.Context, cmd *cli.Command) error {
+				timeEntries, err := exportedTimeEntries(cmd)
+				if err != nil {
+					return err
+				}
+
+				events := make([]*ics.Event, len(timeEntries))
+				for i, timeEntry := range timeEntries {
+					events[i] = ics.FromTimeEntry(timeEntry)
+				}
+
+				return writeExport(cmd, len(timeEntries), func(w io.Writer) error {
+					return ics.Write(w, events)
+				})
+			},
+		},
+	},
+}
+
+// The flags every export has
+func exportFlags() []cli.Flag {
+	return append(dateRangeFlags(),
+		&cli.StringFlag{
+			Name:    "out",
+			Aliases: []string{"o"},
+			Usage:   "Output file, stdout by default",
+		},
+	)
+}
+
+// Returns the time entries started between --from and --to
+func exportedTimeEntries(cmd *cli.Command) ([]*libStore.TimeEntry, error) {
+	from, to, err := dateRange(cmd)
+	if err != nil {
+		return nil, err
+	}
+
+	newDb := db.NewDB()
+	defer newDb.Close()
+
+	store := libStore.NewStore(newDb)
+	return store.GetTimeEntriesQuery(func(q *query.Query) *query.Query {
+		return q.Where(query.Field("start").GtEq(from).And(query.Field("start").LtEq(to))).
+			Sort(query.SortOption{Field: "start", Direction: 1})
+	}), nil
+}
+
+// Writes the export to --out, or to stdout
+func writeExport(cmd *cli.Command, count int, write func(w io.Writer) error) error {
+	path := cmd.String("out")
+	if path == "" {
+		return write(os.Stdout)
+	}
+
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+
+	if err := write(file); err != nil {
+		file.Close()
+		return fmt.Errorf("failed to write %s: %v", path, err)
+	}
+	if err := file.Close(); err != nil {
+		return err
+	}
+
+	pterm.Success.Printfln("Exported %d time entries to %s", count, path)
+	return nil
+}
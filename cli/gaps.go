@@ -0,0 +1,151 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	timeentry "github.com/gyurkovicsferi/time-tracker/lib"
+	"github.com/gyurkovicsferi/time-tracker/lib/db"
+	libStore "github.com/gyurkovicsferi/time-tracker/lib/store"
+	"github.com/pterm/pterm"
+	"github.com/urfave/cli/v3"
+)
+
+var GapsCmd = &cli.Command{
+	Name:        "gaps",
+	Usage:       "List the untracked time within the working hours",
+	Description: "List the intervals of the working hours (see config) without a time entry. With --fill each of them can be tracked as a project / task or added to a neighbouring time entry.",
+	Category:    "reporting",
+	Flags: []cli.Flag{
+		&cli.BoolFlag{
+			Name:  "today",
+			Usage: "Gaps of today (default)",
+		},
+		&cli.BoolFlag{
+			Name:  "week",
+			Usage: "Gaps of this week",
+		},
+		&cli.BoolFlag{
+			Name:  "fill",
+			Usage: "Fill the gaps one by one",
+		},
+	},
+	Action: func(ctx context.Context, cmd *cli.Command) error {
+		db := db.NewDB()
+
+		store := libStore.NewStore(db)
+		defer store.Close()
+
+		from := libStore.StartOfDay(time.Now())
+		if cmd.Bool("week") {
+			from = libStore.StartOfWeek(time.Now())
+		}
+
+		gaps := timeentry.Gaps(store, store.GetSettings(), from, time.Now())
+		if len(gaps) == 0 {
+			pterm.Success.Println("No untracked time")
+			return nil
+		}
+
+		printGaps(gaps)
+		if !cmd.Bool("fill") {
+			return nil
+		}
+
+		filled := 0
+		for _, gap := range gaps {
+			done, err := fillGap(store, gap)
+			if err != nil {
+				return err
+			}
+			if done {
+				filled++
+			}
+		}
+
+		pterm.Success.Printfln("Filled %d of %d gaps", filled, len(gaps))
+		return nil
+	},
+}
+
+func printGaps(gaps []*timeentry.Gap) {
+	var total time.Duration
+	table := pterm.TableData{{"Day", "Start", "End", "Duration"}}
+	for _, gap := range gaps {
+		table = append(table, []string{
+			gap.Start.Local().Format("Mon 2006-01-02"),
+			gap.Start.Local().Format("15:04"),
+			gap.End.Local().Format("15:04"),
+			formatDuration(gap.Duration()),
+		})
+		total += gap.Duration()
+	}
+	pterm.DefaultTable.WithHasHeader().WithData(table).Render()
+	pterm.Info.Printfln("Untracked: %s", formatDuration(total))
+}
+
+// Asks how to fill the gap and fills it. Returns false if it was skipped.
+func fillGap(store *libStore.Store, gap *timeentry.Gap) (bool, error) {
+	const (
+		skip  = "Skip"
+		other = "Another project / task"
+	)
+
+	options := []string{skip}
+	extend := make(map[string]*libStore.TimeEntry)
+	if gap.Before != nil {
+		option := fmt.Sprintf("Extend %s / %s (before)", gap.Before.Project, gap.Before.Task)
+		options = append(options, option)
+		extend[option] = gap.Before
+	}
+	if gap.After != nil {
+		option := fmt.Sprintf("Extend %s / %s (after)", gap.After.Project, gap.After.Task)
+		options = append(options, option)
+		extend[option] = gap.After
+	}
+	tasks := make(map[string]*libStore.TimeEntry)
+	for _, timeEntry := range recentTasks(store) {
+		option := timeEntry.Project + " / " + timeEntry.Task
+		options = append(options, option)
+		tasks[option] = timeEntry
+	}
+	options = append(options, other)
+
+	selected, err := pterm.DefaultInteractiveSelect.
+		WithOptions(options).
+		WithDefaultText(fmt.Sprintf("%s %s - %s (%s)",
+			gap.Start.Local().Format("Mon 2006-01-02"), gap.Start.Local().Format("15:04"), gap.End.Local().Format("15:04"),
+			formatDuration(gap.Duration()))).
+		Show()
+	if err != nil {
+		return false, err
+	}
+
+	var project, task string
+	switch {
+	case selected == skip:
+		return false, nil
+	case extend[selected] != nil:
+		_, err := timeentry.ExtendIntoGap(store, gap, extend[selected])
+		return err == nil, err
+	case tasks[selected] != nil:
+		project, task = tasks[selected].Project, tasks[selected].Task
+	default:
+		project, err = pterm.DefaultInteractiveTextInput.Show("Project")
+		if err != nil {
+			return false, err
+		}
+		if strings.TrimSpace(project) == "" {
+			return false, fmt.Errorf("project can't be empty")
+		}
+		task, err = pterm.DefaultInteractiveTextInput.Show("Task")
+		if err != nil {
+			return false, err
+		}
+	}
+
+	_, err = timeentry.FillGap(store, gap, strings.TrimSpace(project), strings.TrimSpace(task))
+	return err == nil, err
+}
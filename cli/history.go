@@ -0,0 +1,171 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	timeentry "github.com/gyurkovicsferi/time-tracker/lib"
+	"github.com/gyurkovicsferi/time-tracker/lib/db"
+	"github.com/gyurkovicsferi/time-tracker/lib/provider"
+	s "github.com/gyurkovicsferi/time-tracker/lib/store"
+	"github.com/pterm/pterm"
+	"github.com/urfave/cli/v3"
+)
+
+var HistoryCmd = &cli.Command{
+	Name:        "history",
+	Usage:       "history [id]",
+	Description: "Show the changes of a time entry, or the recent operations without an id",
+	ArgsUsage:   "[id]",
+	Category:    "time-entry",
+	Flags: []cli.Flag{
+		&cli.IntFlag{
+			Name:  "limit",
+			Usage: "Number of recent operations to show",
+			Value: 20,
+		},
+	},
+	Action: func(ctx context.Context, cmd *cli.Command) error {
+		newDB := db.NewDB()
+		defer newDB.Close()
+
+		store := s.NewStore(newDB)
+
+		id := cmd.Args().First()
+		if id != "" {
+			changes := store.GetHistory(id)
+			if len(changes) == 0 {
+				return fmt.Errorf("no history for time entry %s", id)
+			}
+			printHistory(changes)
+			return nil
+		}
+
+		operations := store.GetRecentHistory(int(cmd.Int("limit")))
+		if len(operations) == 0 {
+			pterm.Info.Println("No changes yet")
+			return nil
+		}
+
+		changes := make([]*s.HistoryEntry, 0)
+		for _, operation := range operations {
+			changes = append(changes, operation...)
+		}
+		printHistory(changes)
+		return nil
+	},
+}
+
+var UndoCmd = &cli.Command{
+	Name:        "undo",
+	Usage:       "undo [n]",
+	Description: "Revert the last n operations (1 by default). Operations whose time entries were changed since are refused unless --force is given.",
+	ArgsUsage:   "[n]",
+	Category:    "time-entry",
+	Flags: []cli.Flag{
+		&cli.BoolFlag{
+			Name:  "force",
+			Usage: "Undo even if the time entries were changed since",
+		},
+	},
+	Action: func(ctx context.Context, cmd *cli.Command) error {
+		n := 1
+		if arg := cmd.Args().First(); arg != "" {
+			var err error
+			n, err = strconv.Atoi(arg)
+			if err != nil || n < 1 {
+				return fmt.Errorf("invalid number of operations %q", arg)
+			}
+		}
+
+		newDB := db.NewDB()
+		defer newDB.Close()
+
+		store := s.NewStore(newDB)
+		ledger := provider.NewLedgerStore(newDB)
+
+		undone, err := timeentry.Undo(store, n, cmd.Bool("force"))
+		if err != nil {
+			return err
+		}
+
+		for _, change := range undone {
+			if change.Operation == s.HistoryDelete {
+				if err := ledger.UnmarkDeleted(change.TimeEntryID); err != nil {
+					return err
+				}
+			}
+			if change.Operation == s.HistoryCreate {
+				if err := ledger.MarkDeleted(change.TimeEntryID); err != nil {
+					return err
+				}
+			}
+		}
+
+		printHistory(undone)
+		pterm.Success.Printfln("Undone %d change(s)", len(undone))
+		return nil
+	},
+}
+
+func printHistory(changes []*s.HistoryEntry) {
+	table := pterm.TableData{{"Time", "Command", "Operation", "ID", "Changes"}}
+	for _, change := range changes {
+		operation := string(change.Operation)
+		switch {
+		case change.Undone:
+			operation += " (undone)"
+		case change.UndoOf != "":
+			operation += " (undo)"
+		}
+
+		table = append(table, []string{
+			change.At.Local().Format(time.DateTime),
+			change.Command,
+			operation,
+			change.TimeEntryID,
+			describeChange(change),
+		})
+	}
+	pterm.DefaultTable.WithHasHeader().WithData(table).Render()
+}
+
+func describeChange(change *s.HistoryEntry) string {
+	if change.Before == nil {
+		return describeTimeEntry(change.After)
+	}
+	if change.After == nil {
+		return describeTimeEntry(change.Before)
+	}
+
+	before, after := change.Before, change.After
+	diff := make([]string, 0)
+	field := func(name, from, to string) {
+		if from != to {
+			diff = append(diff, fmt.Sprintf("%s: %s -> %s", name, from, to))
+		}
+	}
+	field("project", before.Project, after.Project)
+	field("task", before.Task, after.Task)
+	field("start", before.Start.Local().Format(time.DateTime), after.Start.Local().Format(time.DateTime))
+	field("end", before.End.Local().Format(time.DateTime), after.End.Local().Format(time.DateTime))
+	field("note", strings.ReplaceAll(before.Note, "\n", " "), strings.ReplaceAll(after.Note, "\n", " "))
+	field("tags", strings.Join(before.Tags, ","), strings.Join(after.Tags, ","))
+
+	if len(diff) == 0 {
+		return "no changes"
+	}
+	return strings.Join(diff, "\n")
+}
+
+func describeTimeEntry(timeEntry *s.TimeEntry) string {
+	return fmt.Sprintf("%s / %s, %s - %s",
+		timeEntry.Project,
+		timeEntry.Task,
+		timeEntry.Start.Local().Format(time.DateTime),
+		timeEntry.End.Local().Format(time.DateTime),
+	)
+}
@@ -0,0 +1,389 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/gyurkovicsferi/time-tracker/lib/csvimport"
+	"github.com/gyurkovicsferi/time-tracker/lib/db"
+	"github.com/gyurkovicsferi/time-tracker/lib/ics"
+	"github.com/gyurkovicsferi/time-tracker/lib/importer"
+	libStore "github.com/gyurkovicsferi/time-tracker/lib/store"
+	"github.com/gyurkovicsferi/time-tracker/lib/timewarrior"
+	"github.com/gyurkovicsferi/time-tracker/lib/toggl"
+	"github.com/pterm/pterm"
+	"github.com/urfave/cli/v3"
+)
+
+var ImportCmd = &cli.Command{
+	Name:        "import",
+	Usage:       "Import time entries from other time trackers",
+	Description: "Import time entries from files, skipping the ones that already exist",
+	Category:    "data",
+	Commands: []*cli.Command{
+		{
+			Name:        "toggl",
+			Usage:       "toggl <file.csv|file.json>",
+			Description: "Import a Toggl Track CSV or JSON export",
+			ArgsUsage:   "<file>",
+			Flags: append([]cli.Flag{
+				&cli.StringFlag{
+					Name:  "format",
+					Usage: "csv or json, detected from the file extension by default",
+				},
+				&cli.StringFlag{
+					Name:  "timezone",
+					Usage: "Timezone of the CSV dates, e.g. Europe/Budapest (defaults to the local one)",
+				},
+			}, importFlags()...),
+			Action: func(ctx context.Context, cmd *cli.Command) error {
+				path := cmd.Args().First()
+				if path == "" {
+					return fmt.Errorf("file is required")
+				}
+
+				loc, err := timezone(cmd)
+				if err != nil {
+					return err
+				}
+
+				file, err := os.Open(path)
+				if err != nil {
+					return err
+				}
+				defer file.Close()
+
+				var timeEntries []*libStore.TimeEntry
+				switch fileFormat(cmd, path) {
+				case "csv":
+					timeEntries, err = toggl.ReadCSV(file, loc)
+				case "json":
+					timeEntries, err = toggl.ReadJSON(file)
+				default:
+					return fmt.Errorf("unknown format, use --format csv or --format json")
+				}
+				if err != nil {
+					return fmt.Errorf("failed to read %s: %v", path, err)
+				}
+
+				return importTimeEntries(cmd, timeEntries, nil)
+			},
+		},
+		{
+			Name:        "timewarrior",
+			Usage:       "timewarrior [dir]",
+			Description: "Import the intervals of a Timewarrior database ($TIMEWARRIORDB or ~/.timewarrior by default). The project and the task are the project: and task: tags, or the first two tags if there are none, the annotation is the note.",
+			ArgsUsage:   "[dir]",
+			Flags:       importFlags(),
+			Action: func(ctx context.Context, cmd *cli.Command) error {
+				dir := cmd.Args().First()
+				if dir == "" {
+					dir = timewarrior.DefaultDir()
+				}
+
+				intervals, err := timewarrior.ReadDir(dir)
+				if err != nil {
+					return fmt.Errorf("failed to read %s: %v", dir, err)
+				}
+
+				timeEntries := make([]*libStore.TimeEntry, 0, len(intervals))
+				var current *libStore.CurrentTimeEntry
+				for _, interval := range intervals {
+					if interval.IsOpen() {
+						current = interval.ToCurrentTimeEntry()
+						continue
+					}
+					timeEntries = append(timeEntries, interval.ToTimeEntry())
+				}
+
+				return importTimeEntries(cmd, timeEntries, current)
+			},
+		},
+		{
+			Name:        "ics",
+			Usage:       "ics <file.ics>",
+			Description: "Import the events of an iCalendar file, e.g. meetings. All-day and cancelled events are skipped, recurring events are imported once.",
+			ArgsUsage:   "<file>",
+			Flags: append([]cli.Flag{
+				&cli.StringFlag{
+					Name:  "project",
+					Usage: "Project of the imported events, unless their summary is \"Project / Task\"",
+					Value: "Meetings",
+				},
+				&cli.StringSliceFlag{
+					Name:  "category",
+					Usage: "Only import the events with one of these categories",
+				},
+				&cli.StringFlag{
+					Name:  "attendee",
+					Usage: "Only import the events with an attendee (or organizer) whose email or name contains this",
+				},
+				&cli.TimestampFlag{
+					Name:  "from",
+					Usage: "Only import the events starting on or after this date",
+					Config: cli.TimestampConfig{
+						Timezone: time.Local,
+						Layouts:  []string{"2006-01-02"},
+					},
+				},
+				&cli.TimestampFlag{
+					Name:  "to",
+					Usage: "Only import the events starting on or before this date",
+					Config: cli.TimestampConfig{
+						Timezone: time.Local,
+						Layouts:  []string{"2006-01-02"},
+					},
+				},
+				&cli.StringFlag{
+					Name:  "timezone",
+					Usage: "Timezone of the event times without one (defaults to the local one)",
+				},
+			}, importFlags()...),
+			Action: func(ctx context.Context, cmd *cli.Command) error {
+				path := cmd.Args().First()
+				if path == "" {
+					return fmt.Errorf("file is required")
+				}
+
+				loc, err := timezone(cmd)
+				if err != nil {
+					return err
+				}
+
+				file, err := os.Open(path)
+				if err != nil {
+					return err
+				}
+				defer file.Close()
+
+				events, err := ics.Read(file, loc)
+				if err != nil {
+					return fmt.Errorf("failed to read %s: %v", path, err)
+				}
+
+				timeEntries := make([]*libStore.TimeEntry, 0, len(events))
+				for _, event := range events {
+					switch {
+					case event.AllDay || event.Cancelled || !event.End.After(event.Start):
+						continue
+					case cmd.IsSet("category") && !event.HasCategory(cmd.StringSlice("category")...):
+						continue
+					case cmd.String("attendee") != "" && !event.HasAttendee(cmd.String("attendee")):
+						continue
+					case HasFlag(cmd, "from") && event.Start.Before(libStore.StartOfDay(cmd.Timestamp("from"))):
+						continue
+					case HasFlag(cmd, "to") && event.Start.After(libStore.EndOfDay(cmd.Timestamp("to"))):
+						continue
+					}
+					timeEntries = append(timeEntries, event.ToTimeEntry(cmd.String("project")))
+				}
+
+				return importTimeEntries(cmd, timeEntries, nil)
+			},
+		},
+		{
+			Name:        "csv",
+			Usage:       "csv <file.csv> --map start=Begin,end=End,project=Client",
+			Description: "Import any CSV file by mapping its columns to the time entry fields: " + strings.Join(csvimport.Fields, ", ") + ". Fields without a mapping are read from the column of the same name. Every row is validated first, nothing is imported while there are invalid rows.",
+			ArgsUsage:   "<file>",
+			Flags: append([]cli.Flag{
+				&cli.StringFlag{
+					Name:  "map",
+					Usage: "Columns of the fields, e.g. start=Begin,end=End,project=Client,task=Activity",
+				},
+				&cli.StringSliceFlag{
+					Name:  "date-format",
+					Usage: "Format of the start and end columns, as a Go layout or strftime (e.g. %d.%m.%Y %H:%M), can be repeated. Defaults to ISO 8601 formats.",
+				},
+				&cli.StringFlag{
+					Name:  "timezone",
+					Usage: "Timezone of the dates without one (defaults to the local one)",
+				},
+				&cli.StringFlag{
+					Name:  "delimiter",
+					Usage: "Column delimiter",
+					Value: ",",
+				},
+				&cli.StringFlag{
+					Name:  "tag-separator",
+					Usage: "Separator of the tags in the tags column",
+					Value: ",",
+				},
+				&cli.StringFlag{
+					Name:  "project",
+					Usage: "Project of the rows without one",
+				},
+				&cli.BoolFlag{
+					Name:  "skip-invalid",
+					Usage: "Import the valid rows even if there are invalid ones",
+				},
+			}, importFlags()...),
+			Action: func(ctx context.Context, cmd *cli.Command) error {
+				path := cmd.Args().First()
+				if path == "" {
+					return fmt.Errorf("file is required")
+				}
+
+				mapping, err := csvimport.ParseMapping(cmd.String("map"))
+				if err != nil {
+					return err
+				}
+
+				loc, err := timezone(cmd)
+				if err != nil {
+					return err
+				}
+
+				delimiter := []rune(strings.ReplaceAll(cmd.String("delimiter"), `\t`, "\t"))
+				if len(delimiter) != 1 {
+					return fmt.Errorf("the delimiter must be a single character")
+				}
+
+				file, err := os.Open(path)
+				if err != nil {
+					return err
+				}
+				defer file.Close()
+
+				timeEntries, rowErrors, err := csvimport.Read(file, csvimport.Options{
+					Mapping:        mapping,
+					DateFormats:    cmd.StringSlice("date-format"),
+					Location:       loc,
+					Delimiter:      delimiter[0],
+					TagSeparator:   cmd.String("tag-separator"),
+					DefaultProject: cmd.String("project"),
+				})
+				if err != nil {
+					return fmt.Errorf("failed to read %s: %v", path, err)
+				}
+
+				for _, rowError := range rowErrors {
+					pterm.Error.Printfln("%s:%d: %v", path, rowError.Line, rowError.Err)
+				}
+				if len(rowErrors) > 0 && !cmd.Bool("skip-invalid") {
+					return fmt.Errorf("%d invalid rows, nothing was imported (use --skip-invalid to import the valid ones)", len(rowErrors))
+				}
+
+				return importTimeEntries(cmd, timeEntries, nil)
+			},
+		},
+	},
+}
+
+// The flags every import has
+func importFlags() []cli.Flag {
+	return []cli.Flag{
+		&cli.BoolFlag{
+			Name:  "dry-run",
+			Usage: "Only show what would be imported",
+		},
+		&cli.BoolFlag{
+			Name:    "yes",
+			Aliases: []string{"y"},
+			Usage:   "Import without asking for confirmation",
+		},
+	}
+}
+
+// Returns --format, or the extension of the file without the dot
+func fileFormat(cmd *cli.Command, path string) string {
+	if format := cmd.String("format"); format != "" {
+		return strings.ToLower(format)
+	}
+	return strings.ToLower(strings.TrimPrefix(filepath.Ext(path), "."))
+}
+
+// Returns the location of --timezone, the local one by default
+func timezone(cmd *cli.Command) (*time.Location, error) {
+	name := cmd.String("timezone")
+	if name == "" {
+		return time.Local, nil
+	}
+
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return nil, fmt.Errorf("invalid timezone %q: %v", name, err)
+	}
+	return loc, nil
+}
+
+// Previews the entries and, once confirmed, inserts the ones that don't exist
+// yet. The running entry of the import (if any) is started unless one is
+// running already.
+func importTimeEntries(cmd *cli.Command, timeEntries []*libStore.TimeEntry, current *libStore.CurrentTimeEntry) error {
+	newDb := db.NewDB()
+	defer newDb.Close()
+
+	store := libStore.NewStore(newDb)
+	candidates := importer.Plan(store, timeEntries)
+
+	if current != nil {
+		running := store.GetCurrentTimeEntry()
+		if running != nil {
+			if !running.Start.Equal(current.Start) {
+				pterm.Warning.Printfln("A time entry is running already, skipping the running %s - %s", current.Project, current.Task)
+			}
+			current = nil
+		} else {
+			pterm.Printfln("Running time entry: %s - %s since %s", current.Project, current.Task, current.Start.Format(time.DateTime))
+		}
+	}
+
+	duplicates := 0
+	table := pterm.TableData{{"Status", "Project", "Task", "Note", "Start", "End", "Duration"}}
+	for _, candidate := range candidates {
+		status := "new"
+		if candidate.Duplicate {
+			status = "duplicate"
+			duplicates++
+		}
+
+		timeEntry := candidate.TimeEntry
+		table = append(table, []string{
+			status,
+			timeEntry.Project,
+			timeEntry.Task,
+			strings.ReplaceAll(timeEntry.Note, "\n", " "),
+			timeEntry.Start.Format(time.DateTime),
+			timeEntry.End.Format(time.DateTime),
+			formatDuration(timeEntry.End.Sub(timeEntry.Start)),
+		})
+	}
+
+	newEntries := len(candidates) - duplicates
+	if len(candidates) > 0 {
+		pterm.DefaultTable.WithHasHeader().WithData(table).Render()
+	}
+	pterm.Printfln("%d new time entries, %d duplicates", newEntries, duplicates)
+
+	if cmd.Bool("dry-run") || (newEntries == 0 && current == nil) {
+		return nil
+	}
+
+	if !cmd.Bool("yes") {
+		question := fmt.Sprintf("Import %d time entries?", newEntries)
+		if current != nil {
+			question = fmt.Sprintf("Import %d time entries and the running one?", newEntries)
+		}
+		confirmed, err := pterm.DefaultInteractiveConfirm.Show(question)
+		if err != nil {
+			return err
+		}
+		if !confirmed {
+			return nil
+		}
+	}
+
+	imported := importer.Apply(store, candidates)
+	pterm.Success.Printfln("Imported %d time entries", len(imported))
+
+	if current != nil {
+		store.InsertCurrentTimeEntry(current)
+		pterm.Success.Printfln("Started %s - %s", current.Project, current.Task)
+	}
+	return nil
+}
@@ -0,0 +1,234 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gyurkovicsferi/time-tracker/lib/apiclient"
+	"github.com/gyurkovicsferi/time-tracker/lib/db"
+	"github.com/gyurkovicsferi/time-tracker/lib/importer"
+	"github.com/gyurkovicsferi/time-tracker/lib/jira"
+	"github.com/gyurkovicsferi/time-tracker/lib/provider"
+	libStore "github.com/gyurkovicsferi/time-tracker/lib/store"
+	"github.com/ostafen/clover/v2/query"
+	"github.com/pterm/pterm"
+	"github.com/urfave/cli/v3"
+)
+
+var JiraCmd = &cli.Command{
+	Name:        "jira",
+	Usage:       "jira",
+	Description: "Log time entries as Jira worklogs. Entries are logged to the issue whose key (e.g. PROJ-123) is in their task, or else in their project, name.",
+	Category:    "Jira",
+	Commands: []*cli.Command{
+		{
+			Name:           "config",
+			Usage:          "config",
+			Description:    "Jira Configuration",
+			DefaultCommand: "get",
+			Commands: []*cli.Command{
+				{
+					Name:        "get",
+					Usage:       "get",
+					Description: "Get the Jira configuration",
+					Action: func(ctx context.Context, cmd *cli.Command) error {
+						newDb := db.NewDB()
+						defer newDb.Close()
+
+						config, err := jira.NewJiraStore(newDb).GetJiraConfig()
+						if err != nil {
+							return err
+						}
+						pterm.Println("URL: " + config.BaseURL)
+						if config.Email != "" {
+							pterm.Println("Email: " + config.Email)
+						}
+						pterm.Println("API token: " + describeSecret(jira.APITokenSources(config)))
+						return nil
+					},
+				},
+				{
+					Name:        "set",
+					Usage:       "set",
+					Description: "Set the Jira configuration. The API token is stored encrypted (--encrypt), or omitted when --api-token-file is given or JIRA_API_TOKEN is set. Without --email the token is used as a personal access token (Jira Server / Data Center).",
+					ArgsUsage:   "<url> [api-token]",
+					Flags: []cli.Flag{
+						&cli.StringFlag{
+							Name:  "email",
+							Usage: "Email of the Jira Cloud account the API token belongs to",
+						},
+						&cli.StringFlag{
+							Name:  "api-token-file",
+							Usage: "Read the API token from this file (must be chmod 600) instead of storing it",
+						},
+						&cli.BoolFlag{
+							Name:  "encrypt",
+							Usage: "Encrypt the stored API token with a passphrase",
+						},
+					},
+					Action: func(ctx context.Context, cmd *cli.Command) error {
+						config := &jira.JiraConfig{
+							BaseURL:      cmd.Args().Get(0),
+							Email:        cmd.String("email"),
+							APITokenFile: cmd.String("api-token-file"),
+						}
+						if config.BaseURL == "" {
+							return fmt.Errorf("URL is required")
+						}
+
+						apiToken := cmd.Args().Get(1)
+						if config.APITokenFile != "" && apiToken != "" {
+							return fmt.Errorf("either pass the API token or --api-token-file, not both")
+						}
+						if apiToken == "" {
+							var err error
+							apiToken, err = jira.ResolveAPIToken(config, nil)
+							if err != nil {
+								return err
+							}
+						} else if err := setAPIToken(config, apiToken, cmd.Bool("encrypt")); err != nil {
+							return err
+						}
+
+						api := jira.NewJiraAPI(config.BaseURL, config.Email, apiToken)
+						user, err := api.GetCurrentUser(ctx)
+						if apiclient.IsStatus(err, http.StatusUnauthorized) || apiclient.IsStatus(err, http.StatusForbidden) {
+							return fmt.Errorf("invalid credentials: Jira rejected the API token")
+						}
+						if err != nil {
+							return err
+						}
+
+						newDb := db.NewDB()
+						defer newDb.Close()
+
+						if err := jira.NewJiraStore(newDb).InsertJiraConfig(config); err != nil {
+							return err
+						}
+						pterm.Success.Println("Logged in to Jira as " + user.DisplayName)
+						return nil
+					},
+				},
+				{
+					Name:        "delete",
+					Usage:       "delete",
+					Description: "Delete the Jira configuration",
+					Action: func(ctx context.Context, cmd *cli.Command) error {
+						newDb := db.NewDB()
+						defer newDb.Close()
+
+						if err := jira.NewJiraStore(newDb).DeleteJiraConfig(); err != nil {
+							return err
+						}
+						pterm.Println("Jira configuration deleted")
+						return nil
+					},
+				},
+			},
+		},
+		{
+			Name:        "push",
+			Usage:       "push --from <date> --to <date>",
+			Description: "Log the time entries of the period as worklogs of the issues in their names",
+			Flags: append(dateRangeFlags(),
+				&cli.BoolFlag{
+					Name:  "dry-run",
+					Usage: "Only show which worklogs would be created or updated",
+				},
+			),
+			Action: func(ctx context.Context, cmd *cli.Command) error {
+				from, to, err := dateRange(cmd)
+				if err != nil {
+					return err
+				}
+
+				if cmd.Bool("dry-run") {
+					return previewJiraPush(from, to)
+				}
+				return pushTimeEntries(ctx, jira.ProviderName, from, to)
+			},
+		},
+		{
+			Name:        "flush",
+			Usage:       "flush",
+			Description: "Upload the queued time entry changes to Jira",
+			Action: func(ctx context.Context, cmd *cli.Command) error {
+				newDb := db.NewDB()
+				defer newDb.Close()
+
+				return flushOutbox(ctx, newDb, promptPassphrase, true, jira.ProviderName)
+			},
+		},
+	},
+}
+
+// Stores the API token encrypted, it is never stored in plaintext
+func setAPIToken(config *jira.JiraConfig, apiToken string, encrypt bool) error {
+	if !encrypt {
+		return fmt.Errorf("the API token is not stored in plaintext, pass --encrypt, --api-token-file or set %s", jira.APITokenEnv)
+	}
+
+	passphrase, err := newPassphrase()
+	if err != nil {
+		return err
+	}
+	config.EncryptedAPIToken, err = apiclient.EncryptSecret(apiToken, passphrase)
+	return err
+}
+
+// Shows what jira push would do, without contacting Jira
+func previewJiraPush(from, to time.Time) error {
+	newDb := db.NewDB()
+	defer newDb.Close()
+
+	store := libStore.NewStore(newDb)
+	ledger := provider.NewLedgerStore(newDb)
+
+	timeEntries := store.GetTimeEntriesQuery(func(q *query.Query) *query.Query {
+		return q.Where(query.Field("start").GtEq(from).And(query.Field("start").LtEq(to))).
+			Sort(query.SortOption{Field: "start", Direction: 1})
+	})
+
+	logged, updated := 0, 0
+	table := pterm.TableData{{"Action", "Issue", "Project", "Task", "Start", "Duration"}}
+	for _, timeEntry := range timeEntries {
+		issueKey := jira.IssueKey(timeEntry.Project, timeEntry.Task)
+
+		link, err := ledger.GetByTimeEntryID(jira.ProviderName, timeEntry.ID)
+		if err != nil {
+			return err
+		}
+
+		action := "log"
+		switch {
+		case link != nil && link.Hash == importer.ContentHash(timeEntry):
+			action = "already logged"
+		case link != nil:
+			action = "update"
+			updated++
+		case issueKey == "":
+			action = "skip: no issue key"
+		case timeEntry.End.Sub(timeEntry.Start) < time.Minute:
+			action = "skip: shorter than a minute"
+		default:
+			logged++
+		}
+
+		table = append(table, []string{
+			action,
+			issueKey,
+			timeEntry.Project,
+			timeEntry.Task,
+			timeEntry.Start.Format(time.DateTime),
+			formatDuration(timeEntry.End.Sub(timeEntry.Start)),
+		})
+	}
+
+	if len(timeEntries) > 0 {
+		pterm.DefaultTable.WithHasHeader().WithData(table).Render()
+	}
+	pterm.Printfln("%d worklogs would be created, %d updated", logged, updated)
+	return nil
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"log"
 	"os"
+	"os/signal"
 
 	"github.com/urfave/cli/v3"
 )
@@ -17,16 +18,36 @@ func main() {
 		Commands: []*cli.Command{
 			StartCmd,
 			StopCmd,
+			ContinueCmd,
 			ListCmd,
 			StatusCmd,
 			EditCmd,
+			AmendCmd,
+			CancelCmd,
+			SplitCmd,
+			MergeCmd,
 			DeleteCmd,
+			HistoryCmd,
+			UndoCmd,
+			TrashCmd,
 			ReportCmd,
+			GapsCmd,
+			CheckCmd,
 			ClockifyCmd,
+			JiraCmd,
+			SyncCmd,
+			ImportCmd,
+			ExportCmd,
+			BackupCmd,
+			RestoreCmd,
+			ConfigCmd,
 		},
 	}
 
-	if err := cmd.Run(context.Background(), os.Args); err != nil {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	if err := cmd.Run(ctx, os.Args); err != nil {
 		log.Fatal(err)
 	}
 }
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"context"
+	"fmt"
+
+	timeentry "github.com/gyurkovicsferi/time-tracker/lib"
+	"github.com/gyurkovicsferi/time-tracker/lib/db"
+	"github.com/gyurkovicsferi/time-tracker/lib/provider"
+	libStore "github.com/gyurkovicsferi/time-tracker/lib/store"
+	"github.com/pterm/pterm"
+	"github.com/urfave/cli/v3"
+)
+
+var MergeCmd = &cli.Command{
+	Name:        "merge",
+	Usage:       "Combine two adjacent time entries",
+	Description: "Combine two adjacent time entries of the same project and task into the earlier one. The later one is moved to the trash and deleted at the providers.",
+	ArgsUsage:   "<id1> <id2>",
+	Category:    "time-entry",
+	Action: func(ctx context.Context, cmd *cli.Command) error {
+		if cmd.Args().Len() != 2 {
+			return fmt.Errorf("the ids of the two time entries are required")
+		}
+
+		db := db.NewDB()
+
+		store := libStore.NewStore(db)
+		defer store.Close()
+
+		timeEntries := make([]*libStore.TimeEntry, 2)
+		for i, id := range cmd.Args().Slice() {
+			timeEntries[i] = store.GetTimeEntry(id)
+			if timeEntries[i] == nil {
+				return fmt.Errorf("time entry %s not found", id)
+			}
+		}
+
+		merged, err := timeentry.Merge(store, timeEntries[0], timeEntries[1])
+		if err != nil {
+			return err
+		}
+
+		ledger := provider.NewLedgerStore(db)
+		for _, timeEntry := range timeEntries {
+			if timeEntry.ID == merged.ID {
+				continue
+			}
+			if err := ledger.MarkDeleted(timeEntry.ID); err != nil {
+				return err
+			}
+		}
+
+		printTimeEntries([]*libStore.TimeEntry{merged})
+		pterm.Success.Println("Merged the time entries")
+		return nil
+	},
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/gyurkovicsferi/time-tracker/lib/apiclient"
+	"github.com/pterm/pterm"
+)
+
+// Describes where the secret is read from, without revealing it
+func describeSecret(sources apiclient.SecretSources) string {
+	if secret := os.Getenv(sources.Env); secret != "" {
+		return apiclient.MaskSecret(secret) + " (from " + sources.Env + ")"
+	}
+	if path := os.Getenv(sources.FileEnv); path != "" {
+		return "file " + path + " (from " + sources.FileEnv + ")"
+	}
+	if sources.File != "" {
+		return "file " + sources.File
+	}
+	if sources.Encrypted != "" {
+		return "encrypted"
+	}
+	if sources.Plaintext != "" {
+		return apiclient.MaskSecret(sources.Plaintext) + " (plaintext)"
+	}
+	return "not set"
+}
+
+func promptPassphrase() (string, error) {
+	return pterm.DefaultInteractiveTextInput.WithMask("*").Show("Passphrase")
+}
+
+func newPassphrase() (string, error) {
+	if passphrase := os.Getenv(apiclient.PassphraseEnv); passphrase != "" {
+		return passphrase, nil
+	}
+
+	passphrase, err := pterm.DefaultInteractiveTextInput.WithMask("*").Show("New passphrase")
+	if err != nil {
+		return "", err
+	}
+	if passphrase == "" {
+		return "", fmt.Errorf("passphrase can't be empty")
+	}
+
+	confirmation, err := pterm.DefaultInteractiveTextInput.WithMask("*").Show("Repeat passphrase")
+	if err != nil {
+		return "", err
+	}
+	if passphrase != confirmation {
+		return "", fmt.Errorf("passphrases don't match")
+	}
+
+	return passphrase, nil
+}
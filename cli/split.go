@@ -0,0 +1,60 @@
+package main
+
+import (
+	"context"
+	"fmt"
+
+	timeentry "github.com/gyurkovicsferi/time-tracker/lib"
+	"github.com/gyurkovicsferi/time-tracker/lib/db"
+	libStore "github.com/gyurkovicsferi/time-tracker/lib/store"
+	"github.com/pterm/pterm"
+	"github.com/urfave/cli/v3"
+)
+
+var SplitCmd = &cli.Command{
+	Name:        "split",
+	Usage:       "Split a time entry into two",
+	Description: "Split the time entry at a time. The second part can be assigned to a different task, synced as a new time entry.",
+	ArgsUsage:   "<id>",
+	Category:    "time-entry",
+	Flags: []cli.Flag{
+		&cli.StringFlag{
+			Name:     "at",
+			Usage:    "Time of the split, e.g. 12:30 (on the day of the time entry) or 2006-01-02 12:30",
+			Required: true,
+		},
+		&cli.StringFlag{
+			Name:  "task",
+			Usage: "Task of the second part",
+		},
+	},
+	Action: func(ctx context.Context, cmd *cli.Command) error {
+		if cmd.Args().Len() != 1 {
+			return fmt.Errorf("the id of the time entry is required")
+		}
+
+		db := db.NewDB()
+
+		store := libStore.NewStore(db)
+		defer store.Close()
+
+		timeEntry := store.GetTimeEntry(cmd.Args().First())
+		if timeEntry == nil {
+			return fmt.Errorf("time entry %s not found", cmd.Args().First())
+		}
+
+		at, err := parseTime(cmd.String("at"), timeEntry.Start)
+		if err != nil {
+			return err
+		}
+
+		first, second, err := timeentry.Split(store, timeEntry, at, cmd.String("task"))
+		if err != nil {
+			return err
+		}
+
+		printTimeEntries([]*libStore.TimeEntry{first, second})
+		pterm.Success.Println("Split the time entry")
+		return nil
+	},
+}
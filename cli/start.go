@@ -45,7 +45,9 @@ var StartCmd = &cli.Command{
 			from = cmd.Timestamp("from")
 		}
 
-		timeentry.NewCurrentTimeEntry(s, cmd.Args().First(), cmd.Args().Get(1), from)
+		if _, err := timeentry.NewCurrentTimeEntry(s, cmd.Args().First(), cmd.Args().Get(1), from); err != nil {
+			return err
+		}
 
 		pterm.NewStyle(pterm.FgGreen).Println("Started time entry: ", cmd.Args().First(), " - ", cmd.Args().Get(1), " at ", from.Format("15:04:05"))
 		return nil
@@ -36,7 +36,13 @@ var StatusCmd = &cli.Command{
 		}
 
 		printStatus(current, cmd.Bool("raw"))
-		return nil
+		if cmd.Bool("raw") {
+			return nil
+		}
+
+		pterm.Println()
+		_, err := checkRunningEntry(store, current, time.Now(), false)
+		return err
 	},
 }
 
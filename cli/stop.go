@@ -8,6 +8,7 @@ import (
 	timeentry "github.com/gyurkovicsferi/time-tracker/lib"
 	"github.com/gyurkovicsferi/time-tracker/lib/db"
 	s "github.com/gyurkovicsferi/time-tracker/lib/store"
+	"github.com/ostafen/clover/v2"
 	"github.com/pterm/pterm"
 	"github.com/urfave/cli/v3"
 )
@@ -45,9 +46,32 @@ var StopCmd = &cli.Command{
 			return nil
 		}
 
-		timeentry.Stop(store, current, end)
-		pterm.Println("Stopped time entry: ", current.Project, current.Task)
+		handled, err := checkRunningEntry(store, current, end, true)
+		if err != nil {
+			return err
+		}
+		if !handled {
+			timeentry.Stop(store, current, end)
+			pterm.Println("Stopped time entry: ", current.Project, current.Task)
+		}
+
+		if store.IsOutboxEnabled() {
+			autoFlush(ctx, db)
+		}
 
 		return nil
 	},
 }
+
+const autoFlushTimeout = 10 * time.Second
+
+// Uploads the queued changes without prompting. Failures are only warned
+// about, the changes stay queued for the next flush.
+func autoFlush(ctx context.Context, db *clover.DB) {
+	ctx, cancel := context.WithTimeout(ctx, autoFlushTimeout)
+	defer cancel()
+
+	if err := flushOutbox(ctx, db, nil, false); err != nil {
+		pterm.Warning.Println("Sync skipped, the changes stay queued: " + err.Error())
+	}
+}
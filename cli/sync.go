@@ -0,0 +1,340 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/gyurkovicsferi/time-tracker/lib/db"
+	"github.com/gyurkovicsferi/time-tracker/lib/provider"
+	libStore "github.com/gyurkovicsferi/time-tracker/lib/store"
+	"github.com/ostafen/clover/v2"
+	"github.com/pterm/pterm"
+	"github.com/urfave/cli/v3"
+)
+
+var SyncCmd = &cli.Command{
+	Name:        "sync",
+	Usage:       "Synchronize time entries with time-tracking services",
+	Description: "Push, pull and flush time entries to the configured sync providers",
+	Category:    "sync",
+	Commands: []*cli.Command{
+		{
+			Name:        "providers",
+			Usage:       "providers",
+			Description: "List the sync providers and check the configured ones",
+			Action: func(ctx context.Context, cmd *cli.Command) error {
+				newDb := db.NewDB()
+				defer newDb.Close()
+
+				table := pterm.TableData{{"Provider", "Status"}}
+				for _, name := range provider.Names() {
+					status := "ok"
+					p, err := provider.New(name, newDb, provider.Options{Passphrase: promptPassphrase})
+					if err == nil {
+						err = p.HealthCheck(ctx)
+					}
+					if errors.Is(err, provider.ErrNotConfigured) {
+						status = "not configured"
+					} else if err != nil {
+						status = "error: " + err.Error()
+					}
+					table = append(table, []string{name, status})
+				}
+
+				return pterm.DefaultTable.WithHasHeader().WithData(table).Render()
+			},
+		},
+		{
+			Name:        "push",
+			Usage:       "push <provider> --from <date> --to <date>",
+			Description: "Upload the new and changed time entries of the period to a provider, and delete the ones deleted locally",
+			ArgsUsage:   "<provider>",
+			Flags:       dateRangeFlags(),
+			Action: func(ctx context.Context, cmd *cli.Command) error {
+				from, to, err := dateRange(cmd)
+				if err != nil {
+					return err
+				}
+				return pushTimeEntries(ctx, cmd.Args().First(), from, to)
+			},
+		},
+		{
+			Name:        "pull",
+			Usage:       "pull <provider> --from <date> --to <date>",
+			Description: "Import the time entries of the period from a provider",
+			ArgsUsage:   "<provider>",
+			Flags:       dateRangeFlags(),
+			Action: func(ctx context.Context, cmd *cli.Command) error {
+				from, to, err := dateRange(cmd)
+				if err != nil {
+					return err
+				}
+				return pullTimeEntries(ctx, cmd.Args().First(), from, to)
+			},
+		},
+		{
+			Name:        "flush",
+			Usage:       "flush [provider...]",
+			Description: "Upload the queued time entry changes, to every configured provider by default",
+			ArgsUsage:   "[provider...]",
+			Action: func(ctx context.Context, cmd *cli.Command) error {
+				newDb := db.NewDB()
+				defer newDb.Close()
+
+				return flushOutbox(ctx, newDb, promptPassphrase, true, cmd.Args().Slice()...)
+			},
+		},
+		newAutoSyncCmd(),
+		{
+			Name:        "mappings",
+			Usage:       "mappings <provider>",
+			Description: "List how local projects are mapped to the projects of a provider",
+			ArgsUsage:   "<provider>",
+			Flags: []cli.Flag{
+				&cli.BoolFlag{
+					Name:  "reset",
+					Usage: "Forget the mappings, they are resolved again on the next upload",
+				},
+			},
+			Action: func(ctx context.Context, cmd *cli.Command) error {
+				name := cmd.Args().First()
+				if name == "" {
+					return fmt.Errorf("provider is required")
+				}
+
+				newDb := db.NewDB()
+				defer newDb.Close()
+
+				ledger := provider.NewLedgerStore(newDb)
+				mappings, err := ledger.GetProjectMappings(name)
+				if err != nil {
+					return err
+				}
+
+				if cmd.Bool("reset") {
+					for _, mapping := range mappings {
+						if err := ledger.DeleteProjectMapping(name, mapping.Project, mapping.Task); err != nil {
+							return err
+						}
+					}
+					pterm.Printfln("Removed %d mappings", len(mappings))
+					return nil
+				}
+
+				table := pterm.TableData{{"Project", "Task", "Remote project", "Remote task"}}
+				for _, mapping := range mappings {
+					table = append(table, []string{mapping.Project, mapping.Task, mapping.RemoteProjectID, mapping.RemoteTaskID})
+				}
+				return pterm.DefaultTable.WithHasHeader().WithData(table).Render()
+			},
+		},
+	},
+}
+
+// Returned as a new command each time, as it is mounted under both sync and
+// clockify config
+func newAutoSyncCmd() *cli.Command {
+	return &cli.Command{
+		Name:        "auto-sync",
+		Usage:       "auto-sync [on|off]",
+		Description: "Queue stopped, edited and deleted time entries and upload them after stop",
+		ArgsUsage:   "[on|off]",
+		Action: func(ctx context.Context, cmd *cli.Command) error {
+			newDb := db.NewDB()
+			defer newDb.Close()
+
+			store := libStore.NewStore(newDb)
+
+			switch cmd.Args().First() {
+			case "":
+			case "on":
+				store.SetOutboxEnabled(true)
+			case "off":
+				store.SetOutboxEnabled(false)
+			default:
+				return fmt.Errorf("expected on or off")
+			}
+
+			if store.IsOutboxEnabled() {
+				pterm.Println("Auto sync: on")
+			} else {
+				pterm.Println("Auto sync: off")
+			}
+			return nil
+		},
+	}
+}
+
+func dateRangeFlags() []cli.Flag {
+	return []cli.Flag{
+		&cli.TimestampFlag{
+			Name:     "from",
+			Usage:    "From date",
+			Required: true,
+			Config: cli.TimestampConfig{
+				Timezone: time.Local,
+				Layouts:  []string{"2006-01-02"},
+			},
+		},
+		&cli.TimestampFlag{
+			Name:  "to",
+			Usage: "To date (inclusive, defaults to today)",
+			Config: cli.TimestampConfig{
+				Timezone: time.Local,
+				Layouts:  []string{"2006-01-02"},
+			},
+		},
+	}
+}
+
+// Returns the start of --from and the end of --to (or today)
+func dateRange(cmd *cli.Command) (time.Time, time.Time, error) {
+	from := libStore.StartOfDay(cmd.Timestamp("from"))
+	to := libStore.EndOfDay(time.Now())
+	if HasFlag(cmd, "to") {
+		to = libStore.EndOfDay(cmd.Timestamp("to"))
+	}
+	if to.Before(from) {
+		return from, to, fmt.Errorf("--to is before --from")
+	}
+
+	return from, to, nil
+}
+
+func newSyncer(newDb *clover.DB, name string, passphrase func() (string, error)) (*provider.Syncer, error) {
+	if name == "" {
+		return nil, fmt.Errorf("provider is required, available: %s", strings.Join(provider.Names(), ", "))
+	}
+
+	p, err := provider.New(name, newDb, provider.Options{Passphrase: passphrase})
+	if err != nil {
+		return nil, err
+	}
+
+	return provider.NewSyncer(libStore.NewStore(newDb), provider.NewLedgerStore(newDb), p), nil
+}
+
+func pushTimeEntries(ctx context.Context, name string, start, end time.Time) error {
+	newDb := db.NewDB()
+	defer newDb.Close()
+
+	syncer, err := newSyncer(newDb, name, promptPassphrase)
+	if err != nil {
+		return err
+	}
+
+	results, err := syncer.Push(ctx, start, end)
+	for _, result := range results {
+		switch {
+		case result.Err != nil && result.Action == provider.SyncActionDeleted:
+			pterm.Error.Println("Failed to delete time entry: " + result.TimeEntry.ID + ": " + result.Err.Error())
+		case result.Err != nil:
+			pterm.Error.Println("Failed to upload time entry: " + result.TimeEntry.Project + " " + result.TimeEntry.Task + ": " + result.Err.Error())
+		case result.Action == provider.SyncActionCreated:
+			pterm.Println("Created time entry: " + result.TimeEntry.Project + " " + result.TimeEntry.Task)
+		case result.Action == provider.SyncActionUpdated:
+			pterm.Println("Updated time entry: " + result.TimeEntry.Project + " " + result.TimeEntry.Task)
+		case result.Action == provider.SyncActionDeleted:
+			pterm.Println("Deleted time entry: " + result.TimeEntry.ID)
+		case result.RemoteID == "":
+			pterm.Println("Skipped time entry: " + result.TimeEntry.Project + " " + result.TimeEntry.Task)
+		default:
+			pterm.Println("Already uploaded time entry: " + result.TimeEntry.ID)
+		}
+	}
+
+	printSyncSummary(provider.Summarize(results))
+	return err
+}
+
+func pullTimeEntries(ctx context.Context, name string, start, end time.Time) error {
+	newDb := db.NewDB()
+	defer newDb.Close()
+
+	syncer, err := newSyncer(newDb, name, promptPassphrase)
+	if err != nil {
+		return err
+	}
+
+	results, err := syncer.Pull(ctx, start, end)
+	imported, skipped := 0, 0
+	for _, result := range results {
+		if result.Action == provider.SyncActionImported {
+			pterm.Println("Imported time entry: " + result.TimeEntry.Project + " " + result.TimeEntry.Task)
+			imported++
+		} else {
+			skipped++
+		}
+	}
+	if err != nil {
+		return err
+	}
+
+	pterm.Success.Printfln("Imported %d time entries, skipped %d", imported, skipped)
+	return nil
+}
+
+// Uploads the queued changes to the named providers, or to every configured
+// one. When verbose is false only failures are reported, as it runs after
+// other commands.
+func flushOutbox(ctx context.Context, newDb *clover.DB, passphrase func() (string, error), verbose bool, names ...string) error {
+	syncers := make([]*provider.Syncer, 0)
+	if len(names) == 0 {
+		providers, err := provider.Configured(newDb, provider.Options{Passphrase: passphrase})
+		if err != nil {
+			return err
+		}
+		for _, p := range providers {
+			syncers = append(syncers, provider.NewSyncer(libStore.NewStore(newDb), provider.NewLedgerStore(newDb), p))
+		}
+	}
+	for _, name := range names {
+		syncer, err := newSyncer(newDb, name, passphrase)
+		if err != nil {
+			return err
+		}
+		syncers = append(syncers, syncer)
+	}
+
+	if len(syncers) == 0 {
+		return fmt.Errorf("no sync provider is configured")
+	}
+
+	results, err := provider.Flush(ctx, libStore.NewStore(newDb), syncers...)
+	for _, result := range results {
+		if result.Err != nil {
+			pterm.Error.Println("Failed to sync time entry " + result.TimeEntry.ID + " to " + result.Provider + ": " + result.Err.Error())
+		} else if verbose {
+			pterm.Println(capitalize(string(result.Action)) + " time entry at " + result.Provider + ": " + result.TimeEntry.ID)
+		}
+	}
+
+	summary := provider.Summarize(results)
+	if verbose || summary.Failed > 0 {
+		printSyncSummary(summary)
+	}
+	return err
+}
+
+func printSyncSummary(summary provider.SyncSummary) {
+	pterm.Printfln("Created: %d, updated: %d, deleted: %d, skipped: %d, failed: %d",
+		summary.Succeeded[provider.SyncActionCreated],
+		summary.Succeeded[provider.SyncActionUpdated],
+		summary.Succeeded[provider.SyncActionDeleted],
+		summary.Succeeded[provider.SyncActionSkipped],
+		summary.Failed,
+	)
+	if summary.Failed > 0 {
+		pterm.Warning.Println("Failed time entries will be retried on the next run")
+	}
+}
+
+func capitalize(s string) string {
+	if s == "" {
+		return s
+	}
+	return strings.ToUpper(s[:1]) + s[1:]
+}
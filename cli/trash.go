@@ -0,0 +1,127 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	timeentry "github.com/gyurkovicsferi/time-tracker/lib"
+	"github.com/gyurkovicsferi/time-tracker/lib/db"
+	"github.com/gyurkovicsferi/time-tracker/lib/provider"
+	s "github.com/gyurkovicsferi/time-tracker/lib/store"
+	"github.com/pterm/pterm"
+	"github.com/urfave/cli/v3"
+)
+
+var TrashCmd = &cli.Command{
+	Name:        "trash",
+	Usage:       "Manage the deleted time entries",
+	Description: "Deleted time entries are kept in the trash until they are purged",
+	Category:    "time-entry",
+	Commands: []*cli.Command{
+		{
+			Name:  "list",
+			Usage: "List the deleted time entries",
+			Action: func(ctx context.Context, cmd *cli.Command) error {
+				newDB := db.NewDB()
+				defer newDB.Close()
+
+				trash := s.NewStore(newDB).GetTrash()
+				if len(trash) == 0 {
+					pterm.Info.Println("The trash is empty")
+					return nil
+				}
+
+				table := pterm.TableData{{"ID", "Project", "Task", "Start", "End", "Duration", "Deleted"}}
+				for _, trashed := range trash {
+					timeEntry := trashed.TimeEntry
+					table = append(table, []string{
+						timeEntry.ID,
+						timeEntry.Project,
+						timeEntry.Task,
+						timeEntry.Start.Local().Format(time.DateTime),
+						timeEntry.End.Local().Format(time.DateTime),
+						formatDuration(timeEntry.End.Sub(timeEntry.Start)),
+						trashed.DeletedAt.Local().Format(time.DateTime),
+					})
+				}
+				pterm.DefaultTable.WithHasHeader().WithData(table).Render()
+				return nil
+			},
+		},
+		{
+			Name:      "restore",
+			Usage:     "Restore deleted time entries",
+			ArgsUsage: "<id>...",
+			Action: func(ctx context.Context, cmd *cli.Command) error {
+				if cmd.Args().Len() == 0 {
+					return fmt.Errorf("id is required")
+				}
+
+				newDB := db.NewDB()
+				defer newDB.Close()
+
+				store := s.NewStore(newDB)
+				ledger := provider.NewLedgerStore(newDB)
+
+				for _, id := range cmd.Args().Slice() {
+					timeEntry, err := timeentry.Restore(store, id)
+					if err != nil {
+						return err
+					}
+					if err := ledger.UnmarkDeleted(id); err != nil {
+						return err
+					}
+					pterm.Success.Printfln("Restored %s / %s (%s)", timeEntry.Project, timeEntry.Task, timeEntry.Start.Local().Format(time.DateTime))
+				}
+				return nil
+			},
+		},
+		{
+			Name:  "purge",
+			Usage: "Permanently delete the time entries in the trash",
+			Flags: []cli.Flag{
+				&cli.StringFlag{
+					Name:  "older-than",
+					Usage: "Only the entries deleted longer ago than this, e.g. 30d, 2w or 12h",
+					Value: "30d",
+				},
+			},
+			Action: func(ctx context.Context, cmd *cli.Command) error {
+				age, err := parseAge(cmd.String("older-than"))
+				if err != nil {
+					return err
+				}
+
+				newDB := db.NewDB()
+				defer newDB.Close()
+
+				purged := s.NewStore(newDB).PurgeTrash(time.Now().Add(-age))
+				pterm.Success.Printfln("Purged %d time entries", purged)
+				return nil
+			},
+		},
+	},
+}
+
+// Parses durations with day (d) and week (w) units besides the ones of
+// time.ParseDuration
+func parseAge(age string) (time.Duration, error) {
+	for suffix, unit := range map[string]time.Duration{"d": 24 * time.Hour, "w": 7 * 24 * time.Hour} {
+		if number, ok := strings.CutSuffix(age, suffix); ok {
+			value, err := strconv.Atoi(number)
+			if err != nil || value < 0 {
+				return 0, fmt.Errorf("invalid age %q", age)
+			}
+			return time.Duration(value) * unit, nil
+		}
+	}
+
+	d, err := time.ParseDuration(age)
+	if err != nil || d < 0 {
+		return 0, fmt.Errorf("invalid age %q, expected e.g. 30d, 2w or 12h", age)
+	}
+	return d, nil
+}
@@ -1,7 +1,9 @@
 package main
 
 import (
+	"fmt"
 	"slices"
+	"time"
 
 	"github.com/urfave/cli/v3"
 )
@@ -9,3 +11,28 @@ import (
 func HasFlag(cmd *cli.Command, flag string) bool {
 	return slices.Contains(cmd.FlagNames(), flag)
 }
+
+// Layouts of the times given in flags. Times without a date are on the day
+// passed to parseTime.
+var (
+	dateTimeLayouts = []string{time.RFC3339, "2006-01-02 15:04:05", "2006-01-02 15:04", "2006-01-02T15:04:05", "2006-01-02T15:04"}
+	timeLayouts     = []string{"15:04:05", "15:04"}
+)
+
+// Parses a local time, e.g. 2024-05-01 12:30 or 12:30 on the day
+func parseTime(value string, day time.Time) (time.Time, error) {
+	for _, layout := range dateTimeLayouts {
+		if t, err := time.ParseInLocation(layout, value, time.Local); err == nil {
+			return t, nil
+		}
+	}
+
+	day = day.Local()
+	for _, layout := range timeLayouts {
+		if t, err := time.ParseInLocation(layout, value, time.Local); err == nil {
+			return time.Date(day.Year(), day.Month(), day.Day(), t.Hour(), t.Minute(), t.Second(), 0, time.Local), nil
+		}
+	}
+
+	return time.Time{}, fmt.Errorf("invalid time %q, expected e.g. 12:30 or 2006-01-02 12:30", value)
+}
@@ -6,6 +6,8 @@ require (
 	github.com/google/uuid v1.6.0
 	github.com/ostafen/clover/v2 v2.0.0-alpha.3
 	github.com/pterm/pterm v0.12.80
+	golang.org/x/term v0.31.0
+	gopkg.in/yaml.v3 v3.0.1
 )
 
 require (
@@ -44,6 +46,5 @@ require (
 	github.com/urfave/cli/v3 v3.1.1
 	github.com/xo/terminfo v0.0.0-20220910002029-abceb7e1c41e // indirect
 	golang.org/x/sys v0.32.0 // indirect
-	golang.org/x/term v0.31.0 // indirect
 	golang.org/x/text v0.24.0 // indirect
 )
@@ -0,0 +1,190 @@
+// Package apiclient holds what the JSON API clients of the sync providers
+// have in common: sending authenticated requests with retries, reporting
+// failed responses and keeping the credentials secret.
+package apiclient
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const (
+	DefaultTimeout   = 30 * time.Second
+	DefaultUserAgent = "time-entry-cli"
+)
+
+type Client struct {
+	baseURL    string
+	userAgent  string
+	httpClient *http.Client
+	// Applied to the client in New, 0 keeps its timeout
+	timeout     time.Duration
+	retryPolicy RetryPolicy
+	sleep       func(ctx context.Context, d time.Duration) error
+	// Adds the credentials to a request
+	authenticate func(req *http.Request)
+}
+
+// Returned for non-2xx responses
+type APIError struct {
+	StatusCode int
+	Message    string
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("unexpected status code: %d: %s", e.StatusCode, e.Message)
+}
+
+func IsStatus(err error, statusCode int) bool {
+	var apiErr *APIError
+	return errors.As(err, &apiErr) && apiErr.StatusCode == statusCode
+}
+
+type Option func(*Client)
+
+// Overrides the API base URL, e.g. to point the client at a fake server
+func WithBaseURL(baseURL string) Option {
+	return func(c *Client) {
+		c.baseURL = strings.TrimSuffix(baseURL, "/")
+	}
+}
+
+// The client is copied, so WithTimeout doesn't change the caller's client
+func WithHTTPClient(httpClient *http.Client) Option {
+	return func(c *Client) {
+		c.httpClient = httpClient
+	}
+}
+
+func WithTimeout(timeout time.Duration) Option {
+	return func(c *Client) {
+		c.timeout = timeout
+	}
+}
+
+func WithUserAgent(userAgent string) Option {
+	return func(c *Client) {
+		c.userAgent = userAgent
+	}
+}
+
+// Replaces waiting between retries, e.g. to record the delays in tests
+func WithSleep(sleep func(ctx context.Context, d time.Duration) error) Option {
+	return func(c *Client) {
+		c.sleep = sleep
+	}
+}
+
+func New(baseURL string, authenticate func(req *http.Request), options ...Option) *Client {
+	client := &Client{
+		baseURL:      strings.TrimSuffix(baseURL, "/"),
+		userAgent:    DefaultUserAgent,
+		retryPolicy:  DefaultRetryPolicy(),
+		sleep:        sleepContext,
+		authenticate: authenticate,
+	}
+
+	for _, option := range options {
+		option(client)
+	}
+
+	httpClient := &http.Client{Timeout: DefaultTimeout}
+	if client.httpClient != nil {
+		copied := *client.httpClient
+		httpClient = &copied
+	}
+	if client.timeout > 0 {
+		httpClient.Timeout = client.timeout
+	}
+	client.httpClient = httpClient
+
+	return client
+}
+
+// Creates an authenticated request of the path with body encoded as JSON
+// (if not nil)
+func (c *Client) NewRequest(ctx context.Context, method, path string, body any) (*http.Request, error) {
+	var reader io.Reader
+	if body != nil {
+		jsonData, err := json.Marshal(body)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal request body: %v", err)
+		}
+		reader = bytes.NewReader(jsonData)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, reader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %v", err)
+	}
+
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("User-Agent", c.userAgent)
+	c.authenticate(req)
+
+	return req, nil
+}
+
+// Sends the request and decodes the response body into result (if not nil).
+// Rate-limited (429) requests are retried with backoff, 5xx and network
+// failures only for idempotent methods: a POST may have been committed before
+// failing, retrying it could create a duplicate.
+func (c *Client) Do(req *http.Request, result any) error {
+	idempotent := isIdempotent(req.Method)
+
+	for retry := 0; ; retry++ {
+		if retry > 0 && req.GetBody != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				return fmt.Errorf("failed to rewind request body: %v", err)
+			}
+			req.Body = body
+		}
+
+		resp, err := c.httpClient.Do(req)
+		if err != nil {
+			if ctxErr := req.Context().Err(); ctxErr != nil {
+				return ctxErr
+			}
+			if idempotent && retry < c.retryPolicy.MaxRetries {
+				if err := c.sleep(req.Context(), c.retryPolicy.delay(retry+1, nil)); err != nil {
+					return err
+				}
+				continue
+			}
+			return fmt.Errorf("failed to send request: %v", err)
+		}
+
+		if resp.StatusCode >= 200 && resp.StatusCode <= 299 {
+			defer resp.Body.Close()
+			if result == nil {
+				return nil
+			}
+			if err := json.NewDecoder(resp.Body).Decode(result); err != nil {
+				return fmt.Errorf("failed to decode response: %v", err)
+			}
+			return nil
+		}
+
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		resp.Body.Close()
+		apiErr := &APIError{StatusCode: resp.StatusCode, Message: strings.TrimSpace(string(body))}
+
+		if !isRetryableStatus(resp.StatusCode, idempotent) || retry >= c.retryPolicy.MaxRetries {
+			return apiErr
+		}
+		if err := c.sleep(req.Context(), c.retryPolicy.delay(retry+1, resp)); err != nil {
+			return err
+		}
+	}
+}
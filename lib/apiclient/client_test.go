@@ -0,0 +1,41 @@
+package apiclient
+
+import (
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestTimeoutDoesNotChangeTheCallersClient(t *testing.T) {
+	httpClient := &http.Client{Timeout: time.Minute}
+
+	for _, options := range [][]Option{
+		{WithHTTPClient(httpClient), WithTimeout(5 * time.Second)},
+		{WithTimeout(5 * time.Second), WithHTTPClient(httpClient)},
+	} {
+		client := New("https://example.com", func(*http.Request) {}, options...)
+
+		if client.httpClient == httpClient {
+			t.Error("the caller's client is used directly")
+		}
+		if client.httpClient.Timeout != 5*time.Second {
+			t.Errorf("got timeout %v, want 5s", client.httpClient.Timeout)
+		}
+	}
+
+	if httpClient.Timeout != time.Minute {
+		t.Errorf("the caller's client timeout changed to %v", httpClient.Timeout)
+	}
+}
+
+func TestTimeoutDefaults(t *testing.T) {
+	if got := New("https://example.com", nil).httpClient.Timeout; got != DefaultTimeout {
+		t.Errorf("got timeout %v, want %v", got, DefaultTimeout)
+	}
+	if got := New("https://example.com", nil, WithHTTPClient(nil), WithTimeout(time.Second)).httpClient.Timeout; got != time.Second {
+		t.Errorf("got timeout %v with a nil client, want 1s", got)
+	}
+	if got := New("https://example.com", nil, WithHTTPClient(&http.Client{Timeout: time.Minute})).httpClient.Timeout; got != time.Minute {
+		t.Errorf("got timeout %v, want the timeout of the client", got)
+	}
+}
@@ -0,0 +1,178 @@
+package apiclient
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/pbkdf2"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"os"
+	"strings"
+)
+
+const (
+	// Passphrase used to decrypt an encrypted secret without prompting
+	PassphraseEnv = "TIME_ENTRY_PASSPHRASE"
+
+	encryptedSecretVersion = "v1"
+	keyDerivationRounds    = 600_000
+	saltSize               = 16
+)
+
+// Returns the passphrase for encrypting / decrypting a secret
+type PassphraseFunc func() (string, error)
+
+// Where a secret, e.g. an API key, can be read from. Resolved in the order of
+// the fields, empty fields are skipped.
+type SecretSources struct {
+	// Name of the environment variable holding the secret
+	Env string
+	// Name of the environment variable holding the path of a secret file
+	FileEnv string
+	// Path of the configured secret file
+	File string
+	// The stored secret, encrypted with EncryptSecret
+	Encrypted string
+	// The stored secret of older configurations
+	Plaintext string
+}
+
+// Returns the secret from the first of its sources that has one, or an empty
+// string if none of them has. Name describes the secret in errors, e.g.
+// "Clockify API key".
+func ResolveSecret(name string, sources SecretSources, passphrase PassphraseFunc) (string, error) {
+	if sources.Env != "" {
+		if secret := strings.TrimSpace(os.Getenv(sources.Env)); secret != "" {
+			return secret, nil
+		}
+	}
+
+	if sources.FileEnv != "" {
+		if path := os.Getenv(sources.FileEnv); path != "" {
+			return ReadSecretFile(path)
+		}
+	}
+
+	if sources.File != "" {
+		return ReadSecretFile(sources.File)
+	}
+
+	if sources.Encrypted != "" {
+		if secret := os.Getenv(PassphraseEnv); secret != "" {
+			return DecryptSecret(sources.Encrypted, secret)
+		}
+		if passphrase == nil {
+			return "", fmt.Errorf("the %s is encrypted, set %s", name, PassphraseEnv)
+		}
+		secret, err := passphrase()
+		if err != nil {
+			return "", err
+		}
+		return DecryptSecret(sources.Encrypted, secret)
+	}
+
+	return sources.Plaintext, nil
+}
+
+// Reads a secret from a file that must not be accessible by group or others
+func ReadSecretFile(path string) (string, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to read secret file: %v", err)
+	}
+
+	if info.Mode().Perm()&0o077 != 0 {
+		return "", fmt.Errorf("secret file %s is accessible by others (mode %04o), run chmod 600 %s", path, info.Mode().Perm(), path)
+	}
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to read secret file: %v", err)
+	}
+
+	secret := strings.TrimSpace(string(content))
+	if secret == "" {
+		return "", fmt.Errorf("secret file %s is empty", path)
+	}
+
+	return secret, nil
+}
+
+// Encrypts the secret with AES-GCM using a key derived from the passphrase
+func EncryptSecret(secret, passphrase string) (string, error) {
+	salt := make([]byte, saltSize)
+	if _, err := rand.Read(salt); err != nil {
+		return "", err
+	}
+
+	gcm, err := newCipher(passphrase, salt)
+	if err != nil {
+		return "", err
+	}
+
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+
+	sealed := gcm.Seal(nil, nonce, []byte(secret), nil)
+	payload := append(append(salt, nonce...), sealed...)
+
+	return encryptedSecretVersion + ":" + base64.StdEncoding.EncodeToString(payload), nil
+}
+
+func DecryptSecret(encrypted, passphrase string) (string, error) {
+	version, encoded, found := strings.Cut(encrypted, ":")
+	if !found || version != encryptedSecretVersion {
+		return "", fmt.Errorf("unsupported encrypted secret format")
+	}
+
+	payload, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return "", fmt.Errorf("failed to decode encrypted secret: %v", err)
+	}
+	if len(payload) < saltSize {
+		return "", fmt.Errorf("encrypted secret is truncated")
+	}
+
+	gcm, err := newCipher(passphrase, payload[:saltSize])
+	if err != nil {
+		return "", err
+	}
+
+	rest := payload[saltSize:]
+	if len(rest) < gcm.NonceSize() {
+		return "", fmt.Errorf("encrypted secret is truncated")
+	}
+
+	secret, err := gcm.Open(nil, rest[:gcm.NonceSize()], rest[gcm.NonceSize():], nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to decrypt secret, wrong passphrase?")
+	}
+
+	return string(secret), nil
+}
+
+func newCipher(passphrase string, salt []byte) (cipher.AEAD, error) {
+	key, err := pbkdf2.Key(sha256.New, passphrase, salt, keyDerivationRounds, 32)
+	if err != nil {
+		return nil, err
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return cipher.NewGCM(block)
+}
+
+// Masks all but the last 4 characters of a secret
+func MaskSecret(secret string) string {
+	if len(secret) <= 4 {
+		return strings.Repeat("*", len(secret))
+	}
+	return strings.Repeat("*", len(secret)-4) + secret[len(secret)-4:]
+}
@@ -0,0 +1,63 @@
+package apiclient
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestEncryptSecret(t *testing.T) {
+	encrypted, err := EncryptSecret("secret", "passphrase")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if secret, err := DecryptSecret(encrypted, "passphrase"); err != nil || secret != "secret" {
+		t.Errorf("got %q, %v, want the secret", secret, err)
+	}
+	if _, err := DecryptSecret(encrypted, "wrong"); err == nil {
+		t.Error("decrypted with a wrong passphrase")
+	}
+}
+
+func TestSecretFileMustBePrivate(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "secret")
+	if err := os.WriteFile(path, []byte("secret\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := ReadSecretFile(path); err == nil {
+		t.Error("read a secret file accessible by others")
+	}
+
+	if err := os.Chmod(path, 0600); err != nil {
+		t.Fatal(err)
+	}
+	if secret, err := ReadSecretFile(path); err != nil || secret != "secret" {
+		t.Errorf("got %q, %v, want the secret", secret, err)
+	}
+}
+
+func TestResolveSecretOrder(t *testing.T) {
+	t.Setenv("TEST_SECRET", "")
+	t.Setenv(PassphraseEnv, "passphrase")
+
+	encrypted, err := EncryptSecret("encrypted", "passphrase")
+	if err != nil {
+		t.Fatal(err)
+	}
+	sources := SecretSources{Env: "TEST_SECRET", Encrypted: encrypted, Plaintext: "plaintext"}
+
+	if secret, err := ResolveSecret("secret", sources, nil); err != nil || secret != "encrypted" {
+		t.Errorf("got %q, %v, want the encrypted secret", secret, err)
+	}
+
+	t.Setenv("TEST_SECRET", "env")
+	if secret, err := ResolveSecret("secret", sources, nil); err != nil || secret != "env" {
+		t.Errorf("got %q, %v, want the secret of the environment", secret, err)
+	}
+
+	if secret, err := ResolveSecret("secret", SecretSources{}, nil); err != nil || secret != "" {
+		t.Errorf("got %q, %v without sources, want nothing", secret, err)
+	}
+}
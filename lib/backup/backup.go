@@ -0,0 +1,306 @@
+// Package backup dumps every collection of the database to a single JSON
+// document and restores it.
+package backup
+
+import (
+	"bufio"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"slices"
+	"time"
+
+	"github.com/gyurkovicsferi/time-tracker/lib/clockify"
+	"github.com/gyurkovicsferi/time-tracker/lib/jira"
+	"github.com/gyurkovicsferi/time-tracker/lib/provider"
+	"github.com/gyurkovicsferi/time-tracker/lib/store"
+	"github.com/ostafen/clover/v2"
+	"github.com/ostafen/clover/v2/document"
+	"github.com/ostafen/clover/v2/query"
+)
+
+// Bumped when collections or fields are added, so an older version of
+// time-entry rejects the backup instead of dropping what it doesn't know.
+// Backups of newer versions are rejected.
+//
+//	1: time entries, outbox, sync ledger and provider configs
+//	2: history, trash, settings, ledger hashes and Jira token sources
+const SchemaVersion = 2
+
+type Mode string
+
+const (
+	// Adds the documents that don't exist yet, keeping the existing ones
+	ModeMerge Mode = "merge"
+	// Deletes every document of the collections first
+	ModeReplace Mode = "replace"
+)
+
+type Backup struct {
+	SchemaVersion int       `json:"schema_version"`
+	CreatedAt     time.Time `json:"created_at"`
+	// Collection name to documents
+	Collections map[string][]json.RawMessage `json:"collections"`
+}
+
+// A collection that is backed up. Documents are decoded into their structs,
+// so their types (e.g. times) survive the JSON round trip.
+type collection struct {
+	name string
+	new  func() any
+	// Holds a single document (e.g. a config), merged only into an empty
+	// collection. The documents of other collections are merged by id.
+	singleton bool
+	// Optional
+	validate func(doc any) error
+}
+
+var collections = []collection{
+	{name: store.TimeEntryCollection, new: func() any { return &store.TimeEntry{} }, validate: validateTimeEntry},
+	{name: store.CurrentTimeEntryCollection, new: func() any { return &store.CurrentTimeEntry{} }, singleton: true},
+	{name: store.HistoryCollection, new: func() any { return &store.HistoryEntry{} }},
+	{name: store.TrashCollection, new: func() any { return &store.TrashEntry{} }},
+	{name: store.OutboxCollection, new: func() any { return &store.OutboxItem{} }},
+	{name: store.OutboxSettingsCollection, new: func() any { return &store.OutboxSettings{} }, singleton: true},
+	{name: store.SettingsCollection, new: func() any { return &store.Settings{} }, singleton: true},
+	{name: provider.LedgerCollection, new: func() any { return &provider.LedgerEntry{} }},
+	{name: provider.ProjectMappingCollection, new: func() any { return &provider.ProjectMapping{} }},
+	{name: clockify.ClockifyConfigCollection, new: func() any { return &clockify.ClockifyConfig{} }, singleton: true},
+	{name: jira.JiraConfigCollection, new: func() any { return &jira.JiraConfig{} }, singleton: true},
+}
+
+func validateTimeEntry(doc any) error {
+	timeEntry := doc.(*store.TimeEntry)
+	if timeEntry.Start.IsZero() || timeEntry.End.IsZero() {
+		return fmt.Errorf("time entry %s has no start or end", timeEntry.ID)
+	}
+	if timeEntry.End.Before(timeEntry.Start) {
+		return fmt.Errorf("time entry %s ends before it starts", timeEntry.ID)
+	}
+	return nil
+}
+
+// Creates the collections (running the migrations of the stores), so every
+// one of them exists before dumping or restoring
+func prepare(db *clover.DB) *store.Store {
+	s := store.NewStore(db)
+	provider.NewLedgerStore(db)
+	clockify.NewClockifyStore(db)
+	jira.NewJiraStore(db)
+	return s
+}
+
+func Create(db *clover.DB) (*Backup, error) {
+	prepare(db)
+
+	backup := &Backup{
+		SchemaVersion: SchemaVersion,
+		CreatedAt:     time.Now(),
+		Collections:   make(map[string][]json.RawMessage, len(collections)),
+	}
+
+	for _, c := range collections {
+		docs, err := db.FindAll(query.NewQuery(c.name))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %v", c.name, err)
+		}
+
+		raw := make([]json.RawMessage, len(docs))
+		for i, doc := range docs {
+			value := c.new()
+			if err := doc.Unmarshal(value); err != nil {
+				return nil, fmt.Errorf("failed to read %s: %v", c.name, err)
+			}
+			raw[i], err = json.Marshal(value)
+			if err != nil {
+				return nil, err
+			}
+		}
+		backup.Collections[c.name] = raw
+	}
+
+	return backup, nil
+}
+
+// Counts the documents per collection
+func (b *Backup) Counts() map[string]int {
+	counts := make(map[string]int, len(b.Collections))
+	for name, docs := range b.Collections {
+		counts[name] = len(docs)
+	}
+	return counts
+}
+
+// Writes the backup as JSON, gzipped if compress is set
+func (b *Backup) Write(w io.Writer, compress bool) error {
+	if !compress {
+		return json.NewEncoder(w).Encode(b)
+	}
+
+	gzipWriter := gzip.NewWriter(w)
+	if err := json.NewEncoder(gzipWriter).Encode(b); err != nil {
+		return err
+	}
+	return gzipWriter.Close()
+}
+
+// Reads a backup written by Write, detecting the compression
+func Read(r io.Reader) (*Backup, error) {
+	reader := bufio.NewReader(r)
+
+	magic, _ := reader.Peek(2)
+	var source io.Reader = reader
+	if len(magic) == 2 && magic[0] == 0x1f && magic[1] == 0x8b {
+		gzipReader, err := gzip.NewReader(reader)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decompress the backup: %v", err)
+		}
+		defer gzipReader.Close()
+		source = gzipReader
+	}
+
+	backup := &Backup{}
+	if err := json.NewDecoder(source).Decode(backup); err != nil {
+		return nil, fmt.Errorf("failed to decode the backup: %v", err)
+	}
+	return backup, nil
+}
+
+// Decoded documents of a collection
+type decoded struct {
+	collection collection
+	docs       []*document.Document
+}
+
+// Checks the version, decodes and validates every document, so a broken
+// backup is rejected before anything is changed
+func (b *Backup) decode() ([]decoded, error) {
+	if b.SchemaVersion < 1 {
+		return nil, fmt.Errorf("not a time-entry backup")
+	}
+	if b.SchemaVersion > SchemaVersion {
+		return nil, fmt.Errorf("the backup has schema version %d, this version of time-entry supports up to %d", b.SchemaVersion, SchemaVersion)
+	}
+
+	for name := range b.Collections {
+		if !slices.ContainsFunc(collections, func(c collection) bool { return c.name == name }) {
+			return nil, fmt.Errorf("unknown collection %q in the backup", name)
+		}
+	}
+
+	result := make([]decoded, 0, len(collections))
+	for _, c := range collections {
+		raw := b.Collections[c.name]
+		if c.singleton && len(raw) > 1 {
+			return nil, fmt.Errorf("%s has %d documents, expected at most one", c.name, len(raw))
+		}
+
+		ids := make(map[string]bool, len(raw))
+		docs := make([]*document.Document, len(raw))
+		for i, message := range raw {
+			value := c.new()
+			if err := json.Unmarshal(message, value); err != nil {
+				return nil, fmt.Errorf("invalid document %d of %s: %v", i+1, c.name, err)
+			}
+
+			if c.validate != nil {
+				if err := c.validate(value); err != nil {
+					return nil, fmt.Errorf("invalid document %d of %s: %v", i+1, c.name, err)
+				}
+			}
+
+			docs[i] = document.NewDocumentOf(value)
+			if docs[i] == nil {
+				return nil, fmt.Errorf("invalid document %d of %s", i+1, c.name)
+			}
+
+			if c.singleton {
+				continue
+			}
+			id, _ := docs[i].Get("id").(string)
+			if id == "" {
+				return nil, fmt.Errorf("document %d of %s has no id", i+1, c.name)
+			}
+			if ids[id] {
+				return nil, fmt.Errorf("duplicate id %s in %s", id, c.name)
+			}
+			ids[id] = true
+		}
+
+		result = append(result, decoded{collection: c, docs: docs})
+	}
+
+	return result, nil
+}
+
+// Validates the backup without restoring it
+func (b *Backup) Validate() error {
+	_, err := b.decode()
+	return err
+}
+
+// Loads the backup into the database, in a single transaction: if anything
+// fails, nothing is changed. Returns the number of restored documents per
+// collection.
+func (b *Backup) Restore(db *clover.DB, mode Mode) (map[string]int, error) {
+	if mode != ModeMerge && mode != ModeReplace {
+		return nil, fmt.Errorf("unknown restore mode %q, expected merge or replace", mode)
+	}
+
+	collectionDocs, err := b.decode()
+	if err != nil {
+		return nil, err
+	}
+
+	restored := make(map[string]int, len(collectionDocs))
+	err = prepare(db).Transaction(func() error {
+		for _, collectionDoc := range collectionDocs {
+			c := collectionDoc.collection
+
+			if mode == ModeReplace {
+				if err := db.Delete(query.NewQuery(c.name)); err != nil {
+					return fmt.Errorf("failed to clear %s: %v", c.name, err)
+				}
+			}
+
+			docs := make([]*document.Document, 0, len(collectionDoc.docs))
+			for _, doc := range collectionDoc.docs {
+				exists, err := existing(db, c, doc)
+				if err != nil {
+					return err
+				}
+				if !exists {
+					docs = append(docs, doc)
+				}
+			}
+
+			if len(docs) > 0 {
+				if err := db.Insert(c.name, docs...); err != nil {
+					return fmt.Errorf("failed to restore %s: %v", c.name, err)
+				}
+			}
+			restored[c.name] = len(docs)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return restored, nil
+}
+
+// Whether the document (or, for singletons, any document) is in the database
+func existing(db *clover.DB, c collection, doc *document.Document) (bool, error) {
+	q := query.NewQuery(c.name)
+	if !c.singleton {
+		q = q.Where(query.Field("id").Eq(doc.Get("id")))
+	}
+
+	exists, err := db.Exists(q)
+	if err != nil {
+		return false, fmt.Errorf("failed to read %s: %v", c.name, err)
+	}
+	return exists, nil
+}
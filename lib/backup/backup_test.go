@@ -0,0 +1,78 @@
+package backup
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gyurkovicsferi/time-tracker/lib/db"
+	"github.com/gyurkovicsferi/time-tracker/lib/store"
+	"github.com/ostafen/clover/v2"
+)
+
+func openDB(t *testing.T) *clover.DB {
+	cdb, err := db.Open(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { cdb.Close() })
+	return cdb
+}
+
+func TestRoundTrip(t *testing.T) {
+	db := openDB(t)
+
+	start := time.Date(2026, 10, 1, 9, 0, 0, 0, time.UTC)
+	store.NewStore(db).InsertTimeEntry(&store.TimeEntry{ID: "1", Project: "Acme", Task: "Development", Start: start, End: start.Add(time.Hour)})
+
+	backup, err := Create(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if backup.SchemaVersion != SchemaVersion {
+		t.Errorf("got schema version %d, want %d", backup.SchemaVersion, SchemaVersion)
+	}
+
+	restored, err := backup.Restore(openDB(t), ModeReplace)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if restored[store.TimeEntryCollection] != 1 {
+		t.Errorf("restored %d time entries, want 1", restored[store.TimeEntryCollection])
+	}
+}
+
+func TestNewerBackupsAreRejectedByVersion(t *testing.T) {
+	backup := &Backup{
+		SchemaVersion: SchemaVersion + 1,
+		Collections:   map[string][]json.RawMessage{"added_later": {}},
+	}
+
+	err := backup.Validate()
+	if err == nil || !strings.Contains(err.Error(), "schema version") {
+		t.Errorf("got %v, want a schema version error", err)
+	}
+}
+
+func TestInvalidBackupChangesNothing(t *testing.T) {
+	db := openDB(t)
+	s := store.NewStore(db)
+	start := time.Date(2026, 10, 1, 9, 0, 0, 0, time.UTC)
+	s.InsertTimeEntry(&store.TimeEntry{ID: "1", Project: "Acme", Task: "Development", Start: start, End: start.Add(time.Hour)})
+
+	backup := &Backup{
+		SchemaVersion: SchemaVersion,
+		Collections: map[string][]json.RawMessage{
+			store.HistoryCollection:   {json.RawMessage(`{"id": "h1"}`)},
+			store.TimeEntryCollection: {json.RawMessage(`{"id": "2", "project": "Acme"}`)},
+		},
+	}
+	if _, err := backup.Restore(db, ModeReplace); err == nil {
+		t.Fatal("expected the time entry without start and end to be rejected")
+	}
+
+	if s.GetTimeEntry("1") == nil {
+		t.Error("the existing time entry was deleted")
+	}
+}
@@ -1,115 +1,256 @@
 package clockify
 
 import (
-	"bytes"
-	"encoding/json"
+	"context"
 	"fmt"
-	"io"
 	"net/http"
+	"net/url"
+	"strconv"
 	"time"
 
+	"github.com/gyurkovicsferi/time-tracker/lib/apiclient"
 	"github.com/gyurkovicsferi/time-tracker/lib/store"
-	"github.com/pterm/pterm"
 )
 
+const DefaultBaseURL = "https://api.clockify.me/api"
+
 type ClockifyAPI struct {
-	apiKey      string
+	client      *apiclient.Client
 	workspaceId string
+	// Optional, used for new time entries without a project mapping
+	defaultProjectID string
+	// Optional, looked up from the API key when empty
+	userID string
+}
+
+func NewClockifyAPI(apiKey, workspaceId string, options ...apiclient.Option) *ClockifyAPI {
+	authenticate := func(req *http.Request) {
+		req.Header.Set("X-Api-Key", apiKey)
+	}
+
+	return &ClockifyAPI{
+		client:      apiclient.New(DefaultBaseURL, authenticate, options...),
+		workspaceId: workspaceId,
+	}
 }
 
-func NewClockifyAPI(apiKey, workspaceId string) *ClockifyAPI {
-	return &ClockifyAPI{apiKey: apiKey, workspaceId: workspaceId}
+// Creates a client for the configured workspace. The options are applied
+// after the configured base URL, so they can override it.
+func NewClockifyAPIFromConfig(config *ClockifyConfig, options ...apiclient.Option) *ClockifyAPI {
+	if config.BaseURL != "" {
+		options = append([]apiclient.Option{apiclient.WithBaseURL(config.BaseURL)}, options...)
+	}
+
+	api := NewClockifyAPI(config.APIKey, config.WorkspaceID, options...)
+	api.userID = config.UserID
+	api.defaultProjectID = config.DefaultProjectID
+	return api
 }
 
 type ClockifyTimeEntryPayload struct {
 	Start       string `json:"start"`
 	End         string `json:"end"`
 	Description string `json:"description"`
-	ProjectID   string `json:"projectId"`
-	TaskID      string `json:"taskId"`
+	ProjectID   string `json:"projectId,omitempty"`
+	TaskID      string `json:"taskId,omitempty"`
 }
 
-// Returns the clockify id of the new time entry
-func (c *ClockifyAPI) PostNewTimeEntry(timeEntry *store.TimeEntry) (string, error) {
-	// Format times in ISO 8601 format
-	startTime := timeEntry.Start.Format(time.RFC3339)
-	endTime := timeEntry.End.Format(time.RFC3339)
+type ClockifyUser struct {
+	ID               string `json:"id"`
+	Name             string `json:"name"`
+	Email            string `json:"email"`
+	ActiveWorkspace  string `json:"activeWorkspace"`
+	DefaultWorkspace string `json:"defaultWorkspace"`
+}
+
+type ClockifyWorkspace struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+type ClockifyProject struct {
+	ID         string `json:"id"`
+	Name       string `json:"name"`
+	ClientName string `json:"clientName"`
+	Archived   bool   `json:"archived"`
+}
+
+type ClockifyTimeInterval struct {
+	Start time.Time  `json:"start"`
+	End   *time.Time `json:"end"`
+}
+
+type ClockifyNamedRef struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+type ClockifyRemoteTimeEntry struct {
+	ID           string               `json:"id"`
+	Description  string               `json:"description"`
+	ProjectID    string               `json:"projectId"`
+	TaskID       string               `json:"taskId"`
+	Project      *ClockifyNamedRef    `json:"project"`
+	Task         *ClockifyNamedRef    `json:"task"`
+	TimeInterval ClockifyTimeInterval `json:"timeInterval"`
+}
+
+func (c *ClockifyAPI) workspacePath(format string, args ...any) string {
+	return "/v1/workspaces/" + url.PathEscape(c.workspaceId) + fmt.Sprintf(format, args...)
+}
+
+// Falls back to the default project when projectID is empty
+func (c *ClockifyAPI) timeEntryPayload(timeEntry *store.TimeEntry, projectID, taskID string) ClockifyTimeEntryPayload {
+	if projectID == "" {
+		projectID = c.defaultProjectID
+	}
 
-	// Create the time entry payload
-	payload := ClockifyTimeEntryPayload{
-		Start:       startTime,
-		End:         endTime,
+	// Format times in ISO 8601 format
+	return ClockifyTimeEntryPayload{
+		Start:       timeEntry.Start.UTC().Format(time.RFC3339),
+		End:         timeEntry.End.UTC().Format(time.RFC3339),
 		Description: fmt.Sprintf("%s - %s", timeEntry.Project, timeEntry.Task),
-		ProjectID:   "65ba4da699f4432f69476fef",
+		ProjectID:   projectID,
+		TaskID:      taskID,
 	}
+}
 
-	// Convert to JSON
-	jsonData, err := json.Marshal(payload)
+// Returns the clockify id of the new time entry
+func (c *ClockifyAPI) PostNewTimeEntry(ctx context.Context, timeEntry *store.TimeEntry, projectID, taskID string) (string, error) {
+	req, err := c.client.NewRequest(ctx, "POST", c.workspacePath("/time-entries"), c.timeEntryPayload(timeEntry, projectID, taskID))
 	if err != nil {
-		return "", fmt.Errorf("failed to marshal time entry: %v", err)
+		return "", err
 	}
 
-	// Create HTTP request
-	req, err := http.NewRequest("POST",
-		fmt.Sprintf("https://api.clockify.me/api/v1/workspaces/%s/time-entries", c.workspaceId),
-		bytes.NewBuffer(jsonData),
-	)
-	if err != nil {
-		return "", fmt.Errorf("failed to create request: %v", err)
+	var result struct {
+		ID string `json:"id"`
+	}
+	if err := c.client.Do(req, &result); err != nil {
+		return "", err
 	}
 
-	// Set headers
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("X-Api-Key", c.apiKey)
+	return result.ID, nil
+}
 
-	// Send request
-	client := &http.Client{}
-	resp, err := client.Do(req)
+func (c *ClockifyAPI) UpdateTimeEntry(ctx context.Context, clockifyID string, timeEntry *store.TimeEntry, projectID, taskID string) error {
+	req, err := c.client.NewRequest(ctx, "PUT", c.workspacePath("/time-entries/%s", url.PathEscape(clockifyID)), c.timeEntryPayload(timeEntry, projectID, taskID))
 	if err != nil {
-		return "", fmt.Errorf("failed to send request: %v", err)
+		return err
 	}
-	defer resp.Body.Close()
 
-	// Check response
-	if resp.StatusCode != http.StatusCreated {
-		body, _ := io.ReadAll(resp.Body)
-		pterm.Println(string(body))
-		return "", fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+	return c.client.Do(req, nil)
+}
+
+func (c *ClockifyAPI) DeleteTimeEntry(ctx context.Context, clockifyID string) error {
+	req, err := c.client.NewRequest(ctx, "DELETE", c.workspacePath("/time-entries/%s", url.PathEscape(clockifyID)), nil)
+	if err != nil {
+		return err
 	}
 
-	// Parse response to get the ID
-	var result struct {
-		ID string `json:"id"`
+	return c.client.Do(req, nil)
+}
+
+// Returns the user the API key belongs to
+func (c *ClockifyAPI) GetCurrentUser(ctx context.Context) (*ClockifyUser, error) {
+	req, err := c.client.NewRequest(ctx, "GET", "/v1/user", nil)
+	if err != nil {
+		return nil, err
 	}
-	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
-		return "", fmt.Errorf("failed to decode response: %v", err)
+
+	user := &ClockifyUser{}
+	if err := c.client.Do(req, user); err != nil {
+		return nil, err
 	}
 
-	return result.ID, nil
+	return user, nil
 }
 
-func (c *ClockifyAPI) DeleteTimeEntry(timeEntry *store.TimeEntry) error {
-	// Create HTTP request
-	req, err := http.NewRequest("DELETE", fmt.Sprintf("https://api.clockify.me/api/v1/workspaces/{workspaceId}/time-entries/%s", timeEntry.ID), nil)
+// Returns the ID of the user the API key belongs to
+func (c *ClockifyAPI) CurrentUserID(ctx context.Context) (string, error) {
+	if c.userID != "" {
+		return c.userID, nil
+	}
+
+	user, err := c.GetCurrentUser(ctx)
 	if err != nil {
-		return fmt.Errorf("failed to create request: %v", err)
+		return "", err
 	}
 
-	// Set headers
-	req.Header.Set("X-Api-Key", c.apiKey)
+	c.userID = user.ID
+	return c.userID, nil
+}
 
-	// Send request
-	client := &http.Client{}
-	resp, err := client.Do(req)
+func (c *ClockifyAPI) GetWorkspaces(ctx context.Context) ([]*ClockifyWorkspace, error) {
+	req, err := c.client.NewRequest(ctx, "GET", "/v1/workspaces", nil)
 	if err != nil {
-		return fmt.Errorf("failed to send request: %v", err)
+		return nil, err
+	}
+
+	var workspaces []*ClockifyWorkspace
+	if err := c.client.Do(req, &workspaces); err != nil {
+		return nil, err
 	}
-	defer resp.Body.Close()
 
-	// Check response
-	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+	return workspaces, nil
+}
+
+// Returns the active projects of the workspace
+func (c *ClockifyAPI) GetProjects(ctx context.Context) ([]*ClockifyProject, error) {
+	projects := make([]*ClockifyProject, 0)
+
+	for page := 1; ; page++ {
+		params := url.Values{}
+		params.Set("archived", "false")
+		params.Set("page", strconv.Itoa(page))
+		params.Set("page-size", strconv.Itoa(pageSize))
+
+		req, err := c.client.NewRequest(ctx, "GET", c.workspacePath("/projects?%s", params.Encode()), nil)
+		if err != nil {
+			return nil, err
+		}
+
+		var pageProjects []*ClockifyProject
+		if err := c.client.Do(req, &pageProjects); err != nil {
+			return nil, err
+		}
+
+		projects = append(projects, pageProjects...)
+
+		if len(pageProjects) < pageSize {
+			return projects, nil
+		}
 	}
+}
+
+const pageSize = 200
+
+// Returns the time entries of the user started between start and end,
+// with the project and task names hydrated
+func (c *ClockifyAPI) GetTimeEntries(ctx context.Context, userId string, start, end time.Time) ([]*ClockifyRemoteTimeEntry, error) {
+	entries := make([]*ClockifyRemoteTimeEntry, 0)
+
+	for page := 1; ; page++ {
+		params := url.Values{}
+		params.Set("start", start.UTC().Format(time.RFC3339))
+		params.Set("end", end.UTC().Format(time.RFC3339))
+		params.Set("hydrated", "true")
+		params.Set("page", strconv.Itoa(page))
+		params.Set("page-size", strconv.Itoa(pageSize))
 
-	return nil
+		req, err := c.client.NewRequest(ctx, "GET", c.workspacePath("/user/%s/time-entries?%s", url.PathEscape(userId), params.Encode()), nil)
+		if err != nil {
+			return nil, err
+		}
+
+		var pageEntries []*ClockifyRemoteTimeEntry
+		if err := c.client.Do(req, &pageEntries); err != nil {
+			return nil, err
+		}
+
+		entries = append(entries, pageEntries...)
+
+		if len(pageEntries) < pageSize {
+			return entries, nil
+		}
+	}
 }
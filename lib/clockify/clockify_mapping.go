@@ -0,0 +1,48 @@
+package clockify
+
+import (
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/gyurkovicsferi/time-tracker/lib/store"
+)
+
+const defaultImportProject = "Clockify"
+
+// Converts a Clockify time entry into a local time entry.
+// Returns nil for entries that are still running.
+func ToTimeEntry(remote *ClockifyRemoteTimeEntry) *store.TimeEntry {
+	if remote.TimeInterval.End == nil {
+		return nil
+	}
+
+	project, task := projectAndTaskOf(remote)
+
+	return &store.TimeEntry{
+		ID:      uuid.New().String(),
+		Project: project,
+		Task:    task,
+		Start:   remote.TimeInterval.Start.Local(),
+		End:     remote.TimeInterval.End.Local(),
+	}
+}
+
+// Maps the Clockify project / task back to local names. Entries without a
+// Clockify project fall back to the "<project> - <task>" description format
+// that is used when uploading.
+func projectAndTaskOf(remote *ClockifyRemoteTimeEntry) (string, string) {
+	description := strings.TrimSpace(remote.Description)
+
+	if remote.Project != nil && remote.Project.Name != "" {
+		if remote.Task != nil && remote.Task.Name != "" {
+			return remote.Project.Name, remote.Task.Name
+		}
+		return remote.Project.Name, description
+	}
+
+	if project, task, found := strings.Cut(description, " - "); found {
+		return strings.TrimSpace(project), strings.TrimSpace(task)
+	}
+
+	return defaultImportProject, description
+}
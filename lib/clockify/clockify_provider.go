@@ -0,0 +1,113 @@
+package clockify
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gyurkovicsferi/time-tracker/lib/apiclient"
+	"github.com/gyurkovicsferi/time-tracker/lib/provider"
+	"github.com/gyurkovicsferi/time-tracker/lib/store"
+	"github.com/ostafen/clover/v2"
+)
+
+const ProviderName = "clockify"
+
+func init() {
+	provider.Register(ProviderName, NewProviderFromDB)
+}
+
+// Clockify as a sync provider
+type ClockifyProvider struct {
+	api      *ClockifyAPI
+	projects []*ClockifyProject
+}
+
+func NewProvider(api *ClockifyAPI) *ClockifyProvider {
+	return &ClockifyProvider{api: api}
+}
+
+// Creates the provider from the stored configuration
+func NewProviderFromDB(db *clover.DB, options provider.Options) (provider.Provider, error) {
+	config, err := NewClockifyStore(db).GetClockifyConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	config.APIKey, err = ResolveAPIKey(config, options.Passphrase)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewProvider(NewClockifyAPIFromConfig(config)), nil
+}
+
+func (p *ClockifyProvider) Name() string {
+	return ProviderName
+}
+
+func (p *ClockifyProvider) HealthCheck(ctx context.Context) error {
+	_, err := p.api.GetCurrentUser(ctx)
+	return err
+}
+
+func (p *ClockifyProvider) Push(ctx context.Context, timeEntry *store.TimeEntry, remoteID string, mapping *provider.ProjectMapping) (string, error) {
+	if remoteID == "" {
+		return p.api.PostNewTimeEntry(ctx, timeEntry, mapping.RemoteProjectID, mapping.RemoteTaskID)
+	}
+	return remoteID, p.api.UpdateTimeEntry(ctx, remoteID, timeEntry, mapping.RemoteProjectID, mapping.RemoteTaskID)
+}
+
+// Deleting an entry that is already gone from Clockify succeeds
+func (p *ClockifyProvider) Delete(ctx context.Context, remoteID string) error {
+	err := p.api.DeleteTimeEntry(ctx, remoteID)
+	if apiclient.IsStatus(err, http.StatusNotFound) {
+		return nil
+	}
+	return err
+}
+
+func (p *ClockifyProvider) Pull(ctx context.Context, start, end time.Time) ([]*provider.RemoteTimeEntry, error) {
+	userID, err := p.api.CurrentUserID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	clockifyEntries, err := p.api.GetTimeEntries(ctx, userID, start, end)
+	if err != nil {
+		return nil, err
+	}
+
+	remoteEntries := make([]*provider.RemoteTimeEntry, len(clockifyEntries))
+	for i, clockifyEntry := range clockifyEntries {
+		remoteEntries[i] = &provider.RemoteTimeEntry{
+			RemoteID:  clockifyEntry.ID,
+			TimeEntry: ToTimeEntry(clockifyEntry),
+		}
+	}
+
+	return remoteEntries, nil
+}
+
+// Maps the local project to the Clockify project with the same name (ignoring
+// case). Projects without a match are uploaded to the default project.
+func (p *ClockifyProvider) MapProject(ctx context.Context, project, task string) (*provider.ProjectMapping, error) {
+	if p.projects == nil {
+		projects, err := p.api.GetProjects(ctx)
+		if err != nil {
+			return nil, err
+		}
+		p.projects = projects
+	}
+
+	mapping := &provider.ProjectMapping{}
+	for _, clockifyProject := range p.projects {
+		if strings.EqualFold(clockifyProject.Name, project) {
+			mapping.RemoteProjectID = clockifyProject.ID
+			break
+		}
+	}
+
+	return mapping, nil
+}
@@ -0,0 +1,421 @@
+package clockify_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/gyurkovicsferi/time-tracker/lib/apiclient"
+	"github.com/gyurkovicsferi/time-tracker/lib/clockify"
+	"github.com/gyurkovicsferi/time-tracker/lib/clockify/clockifytest"
+	"github.com/gyurkovicsferi/time-tracker/lib/provider"
+	"github.com/gyurkovicsferi/time-tracker/lib/store"
+	"github.com/ostafen/clover/v2"
+	"github.com/ostafen/clover/v2/query"
+)
+
+type fixture struct {
+	server *clockifytest.Server
+	store  *store.Store
+	ledger *provider.LedgerStore
+	syncer *provider.Syncer
+}
+
+func newFixture(t *testing.T) *fixture {
+	t.Helper()
+
+	db, err := clover.Open(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	server := clockifytest.NewServer()
+	t.Cleanup(server.Close)
+
+	f := &fixture{
+		server: server,
+		store:  store.NewStore(db),
+		ledger: provider.NewLedgerStore(db),
+	}
+	f.syncer = provider.NewSyncer(f.store, f.ledger, clockify.NewProvider(server.API()))
+	return f
+}
+
+func (f *fixture) insert(t *testing.T, project, task string, start time.Time) *store.TimeEntry {
+	t.Helper()
+
+	timeEntry := &store.TimeEntry{
+		ID:      uuid.New().String(),
+		Project: project,
+		Task:    task,
+		Start:   start,
+		End:     start.Add(time.Hour),
+	}
+	f.store.InsertTimeEntry(timeEntry)
+	return timeEntry
+}
+
+func (f *fixture) remoteID(t *testing.T, timeEntryID string) string {
+	t.Helper()
+
+	link, err := f.ledger.GetByTimeEntryID(clockify.ProviderName, timeEntryID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if link == nil {
+		return ""
+	}
+	return link.RemoteID
+}
+
+func countActions(results []*provider.SyncResult) map[provider.SyncAction]int {
+	return provider.Summarize(results).Succeeded
+}
+
+func allTimeEntries(q *query.Query) *query.Query {
+	return q
+}
+
+var day = time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+
+func TestPushCreatesAndLinksTimeEntries(t *testing.T) {
+	ctx := context.Background()
+	f := newFixture(t)
+	project := f.server.AddProject("Acme")
+
+	mapped := f.insert(t, "acme", "Development", day.Add(9*time.Hour))
+	unmapped := f.insert(t, "Internal", "Meeting", day.Add(11*time.Hour))
+
+	results, err := f.syncer.Push(ctx, day, day.AddDate(0, 0, 1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := countActions(results)[provider.SyncActionCreated]; got != 2 {
+		t.Fatalf("created %d time entries, want 2: %+v", got, results)
+	}
+
+	remote := f.server.TimeEntries()
+	if len(remote) != 2 {
+		t.Fatalf("got %d remote time entries, want 2", len(remote))
+	}
+	for _, timeEntry := range []*store.TimeEntry{mapped, unmapped} {
+		if f.remoteID(t, timeEntry.ID) == "" {
+			t.Errorf("%s / %s is not linked", timeEntry.Project, timeEntry.Task)
+		}
+	}
+	for _, entry := range remote {
+		if entry.ID == f.remoteID(t, mapped.ID) && entry.ProjectID != project.ID {
+			t.Errorf("got project %q, want %q", entry.ProjectID, project.ID)
+		}
+		if entry.ID == f.remoteID(t, unmapped.ID) && entry.ProjectID != "" {
+			t.Errorf("got project %q for an unmapped project", entry.ProjectID)
+		}
+	}
+
+	// Unchanged entries aren't uploaded again
+	results, err = f.syncer.Push(ctx, day, day.AddDate(0, 0, 1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := countActions(results)[provider.SyncActionSkipped]; got != 2 {
+		t.Errorf("skipped %d time entries on the second push, want 2: %+v", got, results)
+	}
+	if got := len(f.server.TimeEntries()); got != 2 {
+		t.Errorf("got %d remote time entries after the second push, want 2", got)
+	}
+}
+
+func TestPushUpdatesChangedTimeEntries(t *testing.T) {
+	ctx := context.Background()
+	f := newFixture(t)
+
+	timeEntry := f.insert(t, "Acme", "Development", day.Add(9*time.Hour))
+	if _, err := f.syncer.Push(ctx, day, day.AddDate(0, 0, 1)); err != nil {
+		t.Fatal(err)
+	}
+	remoteID := f.remoteID(t, timeEntry.ID)
+
+	timeEntry.End = timeEntry.End.Add(30 * time.Minute)
+	timeEntry.Note = "Code review"
+	f.store.UpdateTimeEntry(timeEntry)
+
+	results, err := f.syncer.Push(ctx, day, day.AddDate(0, 0, 1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := countActions(results)[provider.SyncActionUpdated]; got != 1 {
+		t.Fatalf("updated %d time entries, want 1: %+v", got, results)
+	}
+	remote := f.server.TimeEntries()
+	if len(remote) != 1 || remote[0].ID != remoteID || !remote[0].TimeInterval.End.Equal(timeEntry.End) {
+		t.Fatalf("the remote time entry was not updated: %+v", remote)
+	}
+
+	// Updated only once
+	results, err = f.syncer.Push(ctx, day, day.AddDate(0, 0, 1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := countActions(results)[provider.SyncActionUpdated]; got != 0 {
+		t.Errorf("updated %d time entries on the next push, want 0", got)
+	}
+}
+
+func TestPushDeletesTrashedTimeEntries(t *testing.T) {
+	ctx := context.Background()
+	f := newFixture(t)
+
+	trashed := f.insert(t, "Acme", "Development", day.Add(9*time.Hour))
+	restored := f.insert(t, "Acme", "Review", day.Add(11*time.Hour))
+	if _, err := f.syncer.Push(ctx, day, day.AddDate(0, 0, 1)); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, timeEntry := range []*store.TimeEntry{trashed, restored} {
+		f.store.TrashTimeEntry(timeEntry.ID)
+		if err := f.ledger.MarkDeleted(timeEntry.ID); err != nil {
+			t.Fatal(err)
+		}
+	}
+	f.store.InsertTimeEntry(f.store.GetTrashEntry(restored.ID).TimeEntry)
+	f.store.RemoveFromTrash(restored.ID)
+
+	// Deleted whenever they started
+	results, err := f.syncer.Push(ctx, day.AddDate(0, 0, 7), day.AddDate(0, 0, 8))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := countActions(results)[provider.SyncActionDeleted]; got != 1 {
+		t.Fatalf("deleted %d time entries, want 1: %+v", got, results)
+	}
+	if f.remoteID(t, trashed.ID) != "" {
+		t.Error("the deleted time entry is still linked")
+	}
+
+	remote := f.server.TimeEntries()
+	if len(remote) != 1 || remote[0].ID != f.remoteID(t, restored.ID) {
+		t.Fatalf("got remote time entries %+v, want only the restored one", remote)
+	}
+	link, err := f.ledger.GetByTimeEntryID(clockify.ProviderName, restored.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if link.Deleted {
+		t.Error("the restored time entry is still flagged as deleted")
+	}
+}
+
+func TestUnmatchedProjectsAreNotMapped(t *testing.T) {
+	ctx := context.Background()
+	f := newFixture(t)
+
+	f.insert(t, "Acme", "Development", day.Add(9*time.Hour))
+	if _, err := f.syncer.Push(ctx, day, day.AddDate(0, 0, 1)); err != nil {
+		t.Fatal(err)
+	}
+	mapping, err := f.ledger.GetProjectMapping(clockify.ProviderName, "Acme", "Development")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if mapping != nil {
+		t.Fatalf("stored the unmatched mapping %+v", mapping)
+	}
+
+	// Matched on the next run once the project exists remotely
+	project := f.server.AddProject("Acme")
+	f.syncer = provider.NewSyncer(f.store, f.ledger, clockify.NewProvider(f.server.API()))
+	timeEntry := f.insert(t, "Acme", "Development", day.Add(11*time.Hour))
+	if _, err := f.syncer.Push(ctx, day, day.AddDate(0, 0, 1)); err != nil {
+		t.Fatal(err)
+	}
+	for _, entry := range f.server.TimeEntries() {
+		if entry.ID == f.remoteID(t, timeEntry.ID) && entry.ProjectID != project.ID {
+			t.Errorf("got project %q, want %q", entry.ProjectID, project.ID)
+		}
+	}
+	mapping, err = f.ledger.GetProjectMapping(clockify.ProviderName, "Acme", "Development")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if mapping == nil || mapping.RemoteProjectID != project.ID {
+		t.Errorf("got mapping %+v, want project %q", mapping, project.ID)
+	}
+}
+
+func TestMissingAPIKeyIsNotConfigured(t *testing.T) {
+	t.Setenv(clockify.APIKeyEnv, "")
+	t.Setenv(clockify.APIKeyFileEnv, "")
+
+	_, err := clockify.ResolveAPIKey(&clockify.ClockifyConfig{}, nil)
+	if !errors.Is(err, provider.ErrNotConfigured) {
+		t.Errorf("got %v, want an error wrapping provider.ErrNotConfigured", err)
+	}
+}
+
+func TestPushReportsRemoteFailures(t *testing.T) {
+	ctx := context.Background()
+	f := newFixture(t)
+	f.syncer = provider.NewSyncer(f.store, f.ledger, clockify.NewProvider(f.server.API(apiclient.WithRetryPolicy(apiclient.RetryPolicy{}))))
+
+	timeEntry := f.insert(t, "Acme", "Development", day.Add(9*time.Hour))
+
+	// The projects are looked up first
+	f.server.FailNext(1, 400, nil)
+	results, err := f.syncer.Push(ctx, day, day.AddDate(0, 0, 1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if summary := provider.Summarize(results); summary.Failed != 1 {
+		t.Fatalf("got %d failures, want 1", summary.Failed)
+	}
+	if f.remoteID(t, timeEntry.ID) != "" {
+		t.Fatal("a failed upload is linked")
+	}
+
+	// Retried on the next run
+	results, err = f.syncer.Push(ctx, day, day.AddDate(0, 0, 1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := countActions(results)[provider.SyncActionCreated]; got != 1 {
+		t.Fatalf("created %d time entries, want 1: %+v", got, results)
+	}
+}
+
+func TestPullImportsUnlinkedTimeEntries(t *testing.T) {
+	ctx := context.Background()
+	f := newFixture(t)
+
+	end := day.Add(10 * time.Hour)
+	f.server.AddTimeEntry(&clockify.ClockifyRemoteTimeEntry{
+		Description:  "Review",
+		Project:      &clockify.ClockifyNamedRef{ID: "p1", Name: "Acme"},
+		TimeInterval: clockify.ClockifyTimeInterval{Start: day.Add(9 * time.Hour), End: &end},
+	})
+	// Still running
+	f.server.AddTimeEntry(&clockify.ClockifyRemoteTimeEntry{
+		Description:  "Internal - Meeting",
+		TimeInterval: clockify.ClockifyTimeInterval{Start: day.Add(11 * time.Hour)},
+	})
+
+	results, err := f.syncer.Pull(ctx, day, day.AddDate(0, 0, 1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := countActions(results)[provider.SyncActionImported]; got != 1 {
+		t.Fatalf("imported %d time entries, want 1: %+v", got, results)
+	}
+
+	timeEntries := f.store.GetTimeEntriesQuery(allTimeEntries)
+	if len(timeEntries) != 1 {
+		t.Fatalf("got %d local time entries, want 1", len(timeEntries))
+	}
+	if timeEntries[0].Project != "Acme" || timeEntries[0].Task != "Review" {
+		t.Errorf("imported %s / %s, want Acme / Review", timeEntries[0].Project, timeEntries[0].Task)
+	}
+
+	// Imported entries are linked, so they aren't imported again
+	results, err = f.syncer.Pull(ctx, day, day.AddDate(0, 0, 1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := countActions(results)[provider.SyncActionImported]; got != 0 {
+		t.Errorf("imported %d time entries on the second pull, want 0", got)
+	}
+	if got := len(f.store.GetTimeEntriesQuery(allTimeEntries)); got != 1 {
+		t.Errorf("got %d local time entries after the second pull, want 1", got)
+	}
+}
+
+func TestFlushAppliesQueuedChanges(t *testing.T) {
+	ctx := context.Background()
+	f := newFixture(t)
+	f.store.SetOutboxEnabled(true)
+
+	timeEntry := f.insert(t, "Acme", "Development", day.Add(9*time.Hour))
+	f.store.EnqueueOutbox(timeEntry.ID, store.OutboxUpsert)
+
+	results, err := provider.Flush(ctx, f.store, f.syncer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := countActions(results)[provider.SyncActionCreated]; got != 1 {
+		t.Fatalf("created %d time entries, want 1: %+v", got, results)
+	}
+	remoteID := f.remoteID(t, timeEntry.ID)
+	if remoteID == "" {
+		t.Fatal("the flushed time entry is not linked")
+	}
+
+	timeEntry.End = timeEntry.End.Add(30 * time.Minute)
+	f.store.UpdateTimeEntry(timeEntry)
+	f.store.EnqueueOutbox(timeEntry.ID, store.OutboxUpsert)
+
+	results, err = provider.Flush(ctx, f.store, f.syncer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := countActions(results)[provider.SyncActionUpdated]; got != 1 {
+		t.Fatalf("updated %d time entries, want 1: %+v", got, results)
+	}
+	remote := f.server.TimeEntries()
+	if len(remote) != 1 || remote[0].ID != remoteID || !remote[0].TimeInterval.End.Equal(timeEntry.End) {
+		t.Fatalf("the remote time entry was not updated: %+v", remote)
+	}
+
+	f.store.TrashTimeEntry(timeEntry.ID)
+	f.store.EnqueueOutbox(timeEntry.ID, store.OutboxDelete)
+
+	results, err = provider.Flush(ctx, f.store, f.syncer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := countActions(results)[provider.SyncActionDeleted]; got != 1 {
+		t.Fatalf("deleted %d time entries, want 1: %+v", got, results)
+	}
+	if got := len(f.server.TimeEntries()); got != 0 {
+		t.Errorf("got %d remote time entries after the deletion, want 0", got)
+	}
+	if f.remoteID(t, timeEntry.ID) != "" {
+		t.Error("the deleted time entry is still linked")
+	}
+	if got := len(f.store.GetOutbox()); got != 0 {
+		t.Errorf("got %d queued changes after the flushes, want 0", got)
+	}
+}
+
+func TestFlushKeepsFailedChangesQueued(t *testing.T) {
+	ctx := context.Background()
+	f := newFixture(t)
+	f.syncer = provider.NewSyncer(f.store, f.ledger, clockify.NewProvider(f.server.API(apiclient.WithRetryPolicy(apiclient.RetryPolicy{}))))
+	f.store.SetOutboxEnabled(true)
+
+	timeEntry := f.insert(t, "Acme", "Development", day.Add(9*time.Hour))
+	f.store.EnqueueOutbox(timeEntry.ID, store.OutboxUpsert)
+
+	f.server.FailNext(1, 500, nil)
+	results, err := provider.Flush(ctx, f.store, f.syncer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if summary := provider.Summarize(results); summary.Failed != 1 {
+		t.Fatalf("got %d failures, want 1", summary.Failed)
+	}
+	outbox := f.store.GetOutbox()
+	if len(outbox) != 1 || outbox[0].Attempts != 1 {
+		t.Fatalf("the failed change is not queued for a retry: %+v", outbox)
+	}
+
+	if _, err := provider.Flush(ctx, f.store, f.syncer); err != nil {
+		t.Fatal(err)
+	}
+	if got := len(f.store.GetOutbox()); got != 0 {
+		t.Errorf("got %d queued changes after the retry, want 0", got)
+	}
+	if f.remoteID(t, timeEntry.ID) == "" {
+		t.Error("the retried time entry is not linked")
+	}
+}
@@ -0,0 +1,233 @@
+package clockify_test
+
+import (
+	"context"
+	"net/http"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/gyurkovicsferi/time-tracker/lib/apiclient"
+	"github.com/gyurkovicsferi/time-tracker/lib/clockify"
+	"github.com/gyurkovicsferi/time-tracker/lib/clockify/clockifytest"
+	"github.com/gyurkovicsferi/time-tracker/lib/store"
+)
+
+var testPolicy = apiclient.RetryPolicy{
+	MaxRetries: 3,
+	BaseDelay:  time.Second,
+	MaxDelay:   10 * time.Second,
+}
+
+// Returns a client of the server recording the delays instead of waiting
+func retryingAPI(t *testing.T, server *clockifytest.Server) (*clockify.ClockifyAPI, *[]time.Duration) {
+	t.Helper()
+
+	delays := make([]time.Duration, 0)
+	api := server.API(
+		apiclient.WithRetryPolicy(testPolicy),
+		apiclient.WithSleep(func(ctx context.Context, d time.Duration) error {
+			delays = append(delays, d)
+			return nil
+		}),
+	)
+	return api, &delays
+}
+
+func newServer(t *testing.T) *clockifytest.Server {
+	t.Helper()
+
+	server := clockifytest.NewServer()
+	t.Cleanup(server.Close)
+	return server
+}
+
+func testTimeEntry() *store.TimeEntry {
+	start := time.Date(2026, 10, 1, 9, 0, 0, 0, time.UTC)
+	return &store.TimeEntry{ID: "local", Project: "Acme", Task: "Development", Start: start, End: start.Add(time.Hour)}
+}
+
+func TestRetryAfterHeader(t *testing.T) {
+	server := newServer(t)
+	api, delays := retryingAPI(t, server)
+
+	server.FailNext(2, http.StatusTooManyRequests, http.Header{"Retry-After": {"3"}})
+	if _, err := api.GetCurrentUser(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := len(server.Requests()); got != 3 {
+		t.Errorf("got %d requests, want 3", got)
+	}
+	if want := []time.Duration{3 * time.Second, 3 * time.Second}; !slices.Equal(*delays, want) {
+		t.Errorf("got delays %v, want %v", *delays, want)
+	}
+}
+
+func TestRateLimitResetHeader(t *testing.T) {
+	server := newServer(t)
+	api, delays := retryingAPI(t, server)
+
+	server.FailNext(1, http.StatusTooManyRequests, http.Header{
+		"X-Ratelimit-Remaining": {"0"},
+		"X-Ratelimit-Reset":     {"7"},
+	})
+	if _, err := api.GetCurrentUser(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	if want := []time.Duration{7 * time.Second}; !slices.Equal(*delays, want) {
+		t.Errorf("got delays %v, want %v", *delays, want)
+	}
+}
+
+func TestRateLimitDelayIsCapped(t *testing.T) {
+	server := newServer(t)
+	api, delays := retryingAPI(t, server)
+
+	server.FailNext(1, http.StatusTooManyRequests, http.Header{"Retry-After": {"120"}})
+	if _, err := api.GetCurrentUser(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	if want := []time.Duration{testPolicy.MaxDelay}; !slices.Equal(*delays, want) {
+		t.Errorf("got delays %v, want %v", *delays, want)
+	}
+}
+
+func TestExponentialBackoff(t *testing.T) {
+	server := newServer(t)
+	api, delays := retryingAPI(t, server)
+
+	server.FailNext(3, http.StatusServiceUnavailable, nil)
+	if _, err := api.GetCurrentUser(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(*delays) != 3 {
+		t.Fatalf("got %d delays, want 3", len(*delays))
+	}
+	// Jittered within the upper half of 1s, 2s and 4s
+	for i, delay := range *delays {
+		backoff := testPolicy.BaseDelay << i
+		if delay < backoff/2 || delay >= backoff {
+			t.Errorf("retry %d: got delay %v, want [%v, %v)", i+1, delay, backoff/2, backoff)
+		}
+	}
+}
+
+func TestGivesUpAfterMaxRetries(t *testing.T) {
+	server := newServer(t)
+	api, delays := retryingAPI(t, server)
+
+	server.FailNext(10, http.StatusBadGateway, nil)
+	_, err := api.GetCurrentUser(context.Background())
+	if !apiclient.IsStatus(err, http.StatusBadGateway) {
+		t.Fatalf("got error %v, want status 502", err)
+	}
+
+	if got := len(server.Requests()); got != testPolicy.MaxRetries+1 {
+		t.Errorf("got %d requests, want %d", got, testPolicy.MaxRetries+1)
+	}
+	if got := len(*delays); got != testPolicy.MaxRetries {
+		t.Errorf("got %d delays, want %d", got, testPolicy.MaxRetries)
+	}
+}
+
+func TestClientErrorsAreNotRetried(t *testing.T) {
+	server := newServer(t)
+	api, delays := retryingAPI(t, server)
+
+	server.FailNext(1, http.StatusBadRequest, nil)
+	if _, err := api.GetCurrentUser(context.Background()); !apiclient.IsStatus(err, http.StatusBadRequest) {
+		t.Fatalf("got error %v, want status 400", err)
+	}
+
+	if got := len(server.Requests()); got != 1 {
+		t.Errorf("got %d requests, want 1", got)
+	}
+	if len(*delays) != 0 {
+		t.Errorf("got delays %v, want none", *delays)
+	}
+}
+
+func TestPostIsNotRetriedOnServerErrors(t *testing.T) {
+	server := newServer(t)
+	api, delays := retryingAPI(t, server)
+
+	server.FailNext(1, http.StatusBadGateway, nil)
+	_, err := api.PostNewTimeEntry(context.Background(), testTimeEntry(), "", "")
+	if !apiclient.IsStatus(err, http.StatusBadGateway) {
+		t.Fatalf("got error %v, want status 502", err)
+	}
+
+	if got := len(server.Requests()); got != 1 {
+		t.Errorf("got %d requests, want 1", got)
+	}
+	if len(*delays) != 0 {
+		t.Errorf("got delays %v, want none", *delays)
+	}
+}
+
+func TestPostIsRetriedWhenRateLimited(t *testing.T) {
+	server := newServer(t)
+	api, delays := retryingAPI(t, server)
+
+	server.FailNext(1, http.StatusTooManyRequests, http.Header{"Retry-After": {"1"}})
+	if _, err := api.PostNewTimeEntry(context.Background(), testTimeEntry(), "", ""); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := len(server.TimeEntries()); got != 1 {
+		t.Errorf("got %d remote time entries, want 1", got)
+	}
+	if len(*delays) != 1 {
+		t.Errorf("got delays %v, want one", *delays)
+	}
+}
+
+func TestPutIsRetriedWithItsBody(t *testing.T) {
+	server := newServer(t)
+	api, delays := retryingAPI(t, server)
+
+	id, err := api.PostNewTimeEntry(context.Background(), testTimeEntry(), "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	updated := testTimeEntry()
+	updated.End = updated.End.Add(time.Hour)
+	server.FailNext(1, http.StatusServiceUnavailable, nil)
+	if err := api.UpdateTimeEntry(context.Background(), id, updated, "", ""); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(*delays) != 1 {
+		t.Errorf("got delays %v, want one", *delays)
+	}
+	remote := server.TimeEntries()
+	if len(remote) != 1 || !remote[0].TimeInterval.End.Equal(updated.End) {
+		t.Errorf("the retried update was not applied: %+v", remote)
+	}
+}
+
+func TestNetworkErrorsAreRetriedForIdempotentMethods(t *testing.T) {
+	server := clockifytest.NewServer()
+	server.Close()
+	api, delays := retryingAPI(t, server)
+
+	if _, err := api.GetCurrentUser(context.Background()); err == nil {
+		t.Fatal("got no error from a closed server")
+	}
+	if got := len(*delays); got != testPolicy.MaxRetries {
+		t.Errorf("got %d delays for GET, want %d", got, testPolicy.MaxRetries)
+	}
+
+	*delays = (*delays)[:0]
+	if _, err := api.PostNewTimeEntry(context.Background(), testTimeEntry(), "", ""); err == nil {
+		t.Fatal("got no error from a closed server")
+	}
+	if len(*delays) != 0 {
+		t.Errorf("got delays %v for POST, want none", *delays)
+	}
+}
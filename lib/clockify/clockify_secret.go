@@ -0,0 +1,40 @@
+package clockify
+
+import (
+	"fmt"
+
+	"github.com/gyurkovicsferi/time-tracker/lib/apiclient"
+	"github.com/gyurkovicsferi/time-tracker/lib/provider"
+)
+
+const (
+	// Takes precedence over the stored API key
+	APIKeyEnv = "CLOCKIFY_API_KEY"
+	// Path of a file containing the API key, takes precedence over the stored one
+	APIKeyFileEnv = "CLOCKIFY_API_KEY_FILE"
+)
+
+var ErrNoAPIKey = fmt.Errorf("the Clockify API key is %w", provider.ErrNotConfigured)
+
+// The sources of the API key, in order: CLOCKIFY_API_KEY,
+// CLOCKIFY_API_KEY_FILE, the configured key file, the encrypted key and
+// finally the plaintext key of older configurations
+func APIKeySources(config *ClockifyConfig) apiclient.SecretSources {
+	sources := apiclient.SecretSources{Env: APIKeyEnv, FileEnv: APIKeyFileEnv}
+	if config != nil {
+		sources.File = config.APIKeyFile
+		sources.Encrypted = config.EncryptedAPIKey
+		sources.Plaintext = config.APIKey
+	}
+	return sources
+}
+
+// Returns the API key of the configuration from the first of its sources
+// that has one
+func ResolveAPIKey(config *ClockifyConfig, passphrase apiclient.PassphraseFunc) (string, error) {
+	apiKey, err := apiclient.ResolveSecret("Clockify API key", APIKeySources(config), passphrase)
+	if err == nil && apiKey == "" {
+		return "", ErrNoAPIKey
+	}
+	return apiKey, err
+}
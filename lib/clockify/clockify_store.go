@@ -1,15 +1,17 @@
 package clockify
 
 import (
+	"fmt"
 	"log"
 
-	"github.com/gyurkovicsferi/time-tracker/lib/store"
+	"github.com/gyurkovicsferi/time-tracker/lib/provider"
 	"github.com/ostafen/clover/v2"
 	"github.com/ostafen/clover/v2/document"
 	"github.com/ostafen/clover/v2/query"
 )
 
 const (
+	// Deprecated: migrated to the sync ledger
 	ClockifyTimeEntryCollection = "clockify_time_entries"
 	ClockifyConfigCollection    = "clockify_config"
 )
@@ -21,9 +23,21 @@ type ClockifyStore struct {
 type ClockifyConfig struct {
 	ID          string `clover:"id"`
 	WorkspaceID string `clover:"workspace_id"`
-	APIKey      string `clover:"api_key"`
+	// Plaintext API key, only kept for configurations saved without
+	// --encrypt or --api-key-file. Use ResolveAPIKey to get the key.
+	APIKey          string `clover:"api_key"`
+	APIKeyFile      string `clover:"api_key_file"`
+	EncryptedAPIKey string `clover:"encrypted_api_key"`
+	// Optional, defaults to DefaultBaseURL
+	BaseURL string `clover:"base_url"`
+	// Cached by clockify login
+	UserID           string `clover:"user_id"`
+	DefaultProjectID string `clover:"default_project_id"`
 }
 
+var ErrNotConfigured = fmt.Errorf("clockify is %w, run: time-entry clockify login", provider.ErrNotConfigured)
+
+// Deprecated: links are stored as provider.LedgerEntry
 type ClockifyTimeEntry struct {
 	ID          string `clover:"id"`
 	TimeEntryID string `clover:"time_entry_id"`
@@ -35,22 +49,50 @@ func NewClockifyStore(cloverDB *clover.DB) *ClockifyStore {
 	store := &ClockifyStore{
 		db: cloverDB,
 	}
-	store.createCollectionIfNotExists()
 	store.createConfigCollectionIfNotExists()
+	store.migrateTimeEntriesToLedger()
 	return store
 }
 
-func (s *ClockifyStore) createCollectionIfNotExists() {
+// The links to Clockify time entries used to be stored in their own
+// collection, they are kept in the shared sync ledger now
+func (s *ClockifyStore) migrateTimeEntriesToLedger() {
 	hasCollection, err := s.db.HasCollection(ClockifyTimeEntryCollection)
 	if err != nil {
 		log.Fatal(err)
 	}
 
 	if !hasCollection {
-		err = s.db.CreateCollection(ClockifyTimeEntryCollection)
+		return
+	}
+
+	docs, err := s.db.FindAll(query.NewQuery(ClockifyTimeEntryCollection))
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	ledger := provider.NewLedgerStore(s.db)
+	for _, doc := range docs {
+		clockifyTimeEntry := &ClockifyTimeEntry{}
+		if err := doc.Unmarshal(clockifyTimeEntry); err != nil {
+			log.Fatal(err)
+		}
+
+		err := ledger.Insert(ProviderName, clockifyTimeEntry.TimeEntryID, clockifyTimeEntry.ClockifyID, "")
 		if err != nil {
 			log.Fatal(err)
 		}
+		if clockifyTimeEntry.Deleted {
+			err = ledger.MarkDeleted(clockifyTimeEntry.TimeEntryID)
+			if err != nil {
+				log.Fatal(err)
+			}
+		}
+	}
+
+	err = s.db.DropCollection(ClockifyTimeEntryCollection)
+	if err != nil {
+		log.Fatal(err)
 	}
 }
 
@@ -67,59 +109,14 @@ func (s *ClockifyStore) createConfigCollectionIfNotExists() {
 		}
 	}
 }
-func (s *ClockifyStore) InsertTimeEntry(timeEntry *store.TimeEntry, clockifyID string) error {
-	clockifyTimeEntry := &ClockifyTimeEntry{
-		TimeEntryID: timeEntry.ID,
-		ClockifyID:  clockifyID,
-		Deleted:     false,
-	}
-
-	doc := document.NewDocumentOf(clockifyTimeEntry)
-	return s.db.Insert(ClockifyTimeEntryCollection, doc)
-}
-
-func (s *ClockifyStore) MakeClockifyTimeEntryDeleted(timeEntryID string) error {
-	return s.db.Update(query.NewQuery(ClockifyTimeEntryCollection).
-		Where(query.Field("time_entry_id").
-			Eq(timeEntryID),
-		),
-		map[string]interface{}{
-			"deleted": true,
-		},
-	)
-}
-
-func (s *ClockifyStore) GetClockifyTimeEntry(timeEntry *store.TimeEntry) (*ClockifyTimeEntry, error) {
-	doc, err := s.db.FindFirst(query.NewQuery(ClockifyTimeEntryCollection).
-		Where(query.Field("time_entry_id").
-			Eq(timeEntry.ID),
-		),
-	)
-
+func (s *ClockifyStore) GetClockifyConfig() (*ClockifyConfig, error) {
+	doc, err := s.db.FindFirst(query.NewQuery(ClockifyConfigCollection))
 	if err != nil {
 		return nil, err
 	}
 
 	if doc == nil {
-		return nil, nil
-	}
-
-	return unmarshalClockifyTimeEntry(doc), nil
-}
-
-func unmarshalClockifyTimeEntry(doc *document.Document) *ClockifyTimeEntry {
-	clockifyTimeEntry := &ClockifyTimeEntry{}
-	err := doc.Unmarshal(clockifyTimeEntry)
-	if err != nil {
-		log.Fatal(err)
-	}
-	return clockifyTimeEntry
-}
-
-func (s *ClockifyStore) GetClockifyConfig() (*ClockifyConfig, error) {
-	doc, err := s.db.FindFirst(query.NewQuery(ClockifyConfigCollection))
-	if err != nil {
-		return nil, err
+		return nil, ErrNotConfigured
 	}
 
 	return unmarshalClockifyConfig(doc), nil
@@ -0,0 +1,335 @@
+// Package clockifytest provides an in-memory fake of the Clockify API,
+// so the Clockify sync can be exercised without network access.
+package clockifytest
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gyurkovicsferi/time-tracker/lib/apiclient"
+	"github.com/gyurkovicsferi/time-tracker/lib/clockify"
+)
+
+const (
+	APIKey      = "test-api-key"
+	UserID      = "test-user"
+	WorkspaceID = "test-workspace"
+)
+
+type Server struct {
+	*httptest.Server
+
+	mu          sync.Mutex
+	nextID      int
+	user        clockify.ClockifyUser
+	workspaceID string
+	entries     []*clockify.ClockifyRemoteTimeEntry
+	projects    []*clockify.ClockifyProject
+	requests    []*http.Request
+	failures    []failure
+}
+
+type failure struct {
+	status int
+	header http.Header
+}
+
+// Starts a fake Clockify server accepting APIKey for UserID in WorkspaceID.
+// The server is closed by calling Close.
+func NewServer() *Server {
+	s := &Server{
+		user: clockify.ClockifyUser{
+			ID:               UserID,
+			Name:             "Test User",
+			Email:            "test@example.com",
+			ActiveWorkspace:  WorkspaceID,
+			DefaultWorkspace: WorkspaceID,
+		},
+		workspaceID: WorkspaceID,
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /v1/user", s.getUser)
+	mux.HandleFunc("GET /v1/workspaces", s.listWorkspaces)
+	mux.HandleFunc("GET /v1/workspaces/{workspaceId}/projects", s.listProjects)
+	mux.HandleFunc("GET /v1/workspaces/{workspaceId}/user/{userId}/time-entries", s.listTimeEntries)
+	mux.HandleFunc("POST /v1/workspaces/{workspaceId}/time-entries", s.createTimeEntry)
+	mux.HandleFunc("PUT /v1/workspaces/{workspaceId}/time-entries/{id}", s.updateTimeEntry)
+	mux.HandleFunc("DELETE /v1/workspaces/{workspaceId}/time-entries/{id}", s.deleteTimeEntry)
+
+	s.Server = httptest.NewServer(s.authenticate(mux))
+	return s
+}
+
+// Returns a client configured against the fake server
+func (s *Server) API(options ...apiclient.Option) *clockify.ClockifyAPI {
+	options = append([]apiclient.Option{
+		apiclient.WithBaseURL(s.URL),
+		apiclient.WithHTTPClient(s.Client()),
+	}, options...)
+	return clockify.NewClockifyAPI(APIKey, WorkspaceID, options...)
+}
+
+// Adds a time entry as if it was created in the Clockify web UI
+func (s *Server) AddTimeEntry(entry *clockify.ClockifyRemoteTimeEntry) *clockify.ClockifyRemoteTimeEntry {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if entry.ID == "" {
+		entry.ID = s.newID()
+	}
+	s.entries = append(s.entries, entry)
+	return entry
+}
+
+// Adds a project to the workspace
+func (s *Server) AddProject(name string) *clockify.ClockifyProject {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.nextID++
+	project := &clockify.ClockifyProject{ID: fmt.Sprintf("project-%d", s.nextID), Name: name}
+	s.projects = append(s.projects, project)
+	return project
+}
+
+// Returns a copy of the stored time entries
+func (s *Server) TimeEntries() []*clockify.ClockifyRemoteTimeEntry {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return slices.Clone(s.entries)
+}
+
+// Returns the requests received so far
+func (s *Server) Requests() []*http.Request {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return slices.Clone(s.requests)
+}
+
+// Makes the next n requests fail with the given status and response headers,
+// e.g. 429 with a Retry-After header
+func (s *Server) FailNext(n int, status int, header http.Header) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for range n {
+		s.failures = append(s.failures, failure{status: status, header: header})
+	}
+}
+
+func (s *Server) newID() string {
+	s.nextID++
+	return fmt.Sprintf("entry-%d", s.nextID)
+}
+
+func (s *Server) authenticate(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		s.requests = append(s.requests, r)
+
+		if len(s.failures) > 0 {
+			f := s.failures[0]
+			s.failures = s.failures[1:]
+			s.mu.Unlock()
+
+			for key, values := range f.header {
+				w.Header()[key] = values
+			}
+			writeError(w, f.status, http.StatusText(f.status))
+			return
+		}
+		s.mu.Unlock()
+
+		if r.Header.Get("X-Api-Key") != APIKey {
+			writeError(w, http.StatusUnauthorized, "invalid API key")
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func (s *Server) checkWorkspace(w http.ResponseWriter, r *http.Request) bool {
+	if r.PathValue("workspaceId") != s.workspaceID {
+		writeError(w, http.StatusForbidden, "workspace not found")
+		return false
+	}
+	return true
+}
+
+func (s *Server) getUser(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, s.user)
+}
+
+func (s *Server) listWorkspaces(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, []clockify.ClockifyWorkspace{
+		{ID: s.workspaceID, Name: "Test Workspace"},
+	})
+}
+
+func (s *Server) listProjects(w http.ResponseWriter, r *http.Request) {
+	if !s.checkWorkspace(w, r) {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	page, pageSize := pagination(r)
+	writeJSON(w, http.StatusOK, paginate(s.projects, page, pageSize))
+}
+
+func (s *Server) listTimeEntries(w http.ResponseWriter, r *http.Request) {
+	if !s.checkWorkspace(w, r) {
+		return
+	}
+
+	params := r.URL.Query()
+	start, _ := time.Parse(time.RFC3339, params.Get("start"))
+	end, _ := time.Parse(time.RFC3339, params.Get("end"))
+	page, pageSize := pagination(r)
+
+	s.mu.Lock()
+	matching := make([]*clockify.ClockifyRemoteTimeEntry, 0)
+	for _, entry := range s.entries {
+		entryStart := entry.TimeInterval.Start
+		if !start.IsZero() && entryStart.Before(start) {
+			continue
+		}
+		if !end.IsZero() && entryStart.After(end) {
+			continue
+		}
+		matching = append(matching, entry)
+	}
+	s.mu.Unlock()
+
+	writeJSON(w, http.StatusOK, paginate(matching, page, pageSize))
+}
+
+func pagination(r *http.Request) (int, int) {
+	params := r.URL.Query()
+
+	page, err := strconv.Atoi(params.Get("page"))
+	if err != nil || page < 1 {
+		page = 1
+	}
+	pageSize, err := strconv.Atoi(params.Get("page-size"))
+	if err != nil || pageSize < 1 {
+		pageSize = 50
+	}
+
+	return page, pageSize
+}
+
+func paginate[T any](items []T, page, pageSize int) []T {
+	from := min((page-1)*pageSize, len(items))
+	to := min(from+pageSize, len(items))
+	return items[from:to]
+}
+
+func (s *Server) createTimeEntry(w http.ResponseWriter, r *http.Request) {
+	if !s.checkWorkspace(w, r) {
+		return
+	}
+
+	entry, err := decodeTimeEntry(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, s.AddTimeEntry(entry))
+}
+
+func (s *Server) updateTimeEntry(w http.ResponseWriter, r *http.Request) {
+	if !s.checkWorkspace(w, r) {
+		return
+	}
+
+	entry, err := decodeTimeEntry(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entry.ID = r.PathValue("id")
+	index := slices.IndexFunc(s.entries, func(existing *clockify.ClockifyRemoteTimeEntry) bool {
+		return existing.ID == entry.ID
+	})
+	if index < 0 {
+		writeError(w, http.StatusNotFound, "time entry not found")
+		return
+	}
+
+	s.entries[index] = entry
+	writeJSON(w, http.StatusOK, entry)
+}
+
+func decodeTimeEntry(r *http.Request) (*clockify.ClockifyRemoteTimeEntry, error) {
+	payload := clockify.ClockifyTimeEntryPayload{}
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		return nil, err
+	}
+
+	start, err := time.Parse(time.RFC3339, payload.Start)
+	if err != nil {
+		return nil, fmt.Errorf("invalid start")
+	}
+	entry := &clockify.ClockifyRemoteTimeEntry{
+		Description:  payload.Description,
+		ProjectID:    payload.ProjectID,
+		TaskID:       payload.TaskID,
+		TimeInterval: clockify.ClockifyTimeInterval{Start: start},
+	}
+	if payload.End != "" {
+		end, err := time.Parse(time.RFC3339, payload.End)
+		if err != nil {
+			return nil, fmt.Errorf("invalid end")
+		}
+		entry.TimeInterval.End = &end
+	}
+
+	return entry, nil
+}
+
+func (s *Server) deleteTimeEntry(w http.ResponseWriter, r *http.Request) {
+	if !s.checkWorkspace(w, r) {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	id := r.PathValue("id")
+	index := slices.IndexFunc(s.entries, func(entry *clockify.ClockifyRemoteTimeEntry) bool {
+		return entry.ID == id
+	})
+	if index < 0 {
+		writeError(w, http.StatusNotFound, "time entry not found")
+		return
+	}
+
+	s.entries = slices.Delete(s.entries, index, index+1)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func writeJSON(w http.ResponseWriter, status int, body any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(body)
+}
+
+func writeError(w http.ResponseWriter, status int, message string) {
+	writeJSON(w, status, map[string]any{"code": status, "message": message})
+}
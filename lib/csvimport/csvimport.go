@@ -0,0 +1,325 @@
+// Package csvimport reads time entries from arbitrary CSV files, using a
+// mapping from the time entry fields to the columns of the file.
+package csvimport
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/gyurkovicsferi/time-tracker/lib/store"
+)
+
+// The time entry fields columns can be mapped to
+const (
+	FieldStart    = "start"
+	FieldEnd      = "end"
+	FieldDate     = "date"
+	FieldDuration = "duration"
+	FieldProject  = "project"
+	FieldTask     = "task"
+	FieldNote     = "note"
+	FieldTags     = "tags"
+)
+
+var Fields = []string{FieldStart, FieldEnd, FieldDate, FieldDuration, FieldProject, FieldTask, FieldNote, FieldTags}
+
+// Used when no date format is given
+var DefaultDateFormats = []string{
+	time.RFC3339,
+	"2006-01-02 15:04:05",
+	"2006-01-02 15:04",
+	"2006-01-02T15:04:05",
+	"2006-01-02T15:04",
+}
+
+type Options struct {
+	// Field to column header, fields without a column are looked up by their
+	// own name. Headers are matched ignoring case.
+	Mapping map[string]string
+	// Go layouts or strftime formats (e.g. %Y-%m-%d %H:%M). When the date is
+	// in its own column, the formats apply to "<date> <time>".
+	DateFormats []string
+	// Of the times without a zone
+	Location  *time.Location
+	Delimiter rune
+	// Separates the tags in the tags column, comma by default
+	TagSeparator string
+	// Used for the rows without a project
+	DefaultProject string
+}
+
+// A row that can't be imported
+type RowError struct {
+	Line int
+	Err  error
+}
+
+func (e *RowError) Error() string {
+	return fmt.Sprintf("line %d: %v", e.Line, e.Err)
+}
+
+// Parses a field=Column,field=Column mapping
+func ParseMapping(mapping string) (map[string]string, error) {
+	result := make(map[string]string)
+	if strings.TrimSpace(mapping) == "" {
+		return result, nil
+	}
+
+	for _, pair := range strings.Split(mapping, ",") {
+		field, column, ok := strings.Cut(pair, "=")
+		field = strings.ToLower(strings.TrimSpace(field))
+		column = strings.TrimSpace(column)
+		if !ok || column == "" {
+			return nil, fmt.Errorf("invalid mapping %q, expected field=Column", pair)
+		}
+		if !slices.Contains(Fields, field) {
+			return nil, fmt.Errorf("unknown field %q, available: %s", field, strings.Join(Fields, ", "))
+		}
+		result[field] = column
+	}
+	return result, nil
+}
+
+// Reads and validates every row. The valid rows are returned as time entries
+// together with the errors of the invalid ones, so all problems of a file
+// can be reported at once. An error is only returned when the file can't be
+// read at all.
+func Read(r io.Reader, options Options) ([]*store.TimeEntry, []*RowError, error) {
+	options = withDefaults(options)
+
+	reader := csv.NewReader(r)
+	reader.Comma = options.Delimiter
+	reader.FieldsPerRecord = -1
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read the CSV header: %v", err)
+	}
+
+	columns, err := resolveColumns(header, options.Mapping)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	timeEntries := make([]*store.TimeEntry, 0)
+	rowErrors := make([]*RowError, 0)
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			return timeEntries, rowErrors, nil
+		}
+
+		var parseErr *csv.ParseError
+		if errors.As(err, &parseErr) {
+			rowErrors = append(rowErrors, &RowError{Line: parseErr.StartLine, Err: parseErr.Err})
+			continue
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+
+		line, _ := reader.FieldPos(0)
+		timeEntry, err := parseRow(record, columns, options)
+		if err != nil {
+			rowErrors = append(rowErrors, &RowError{Line: line, Err: err})
+			continue
+		}
+		timeEntries = append(timeEntries, timeEntry)
+	}
+}
+
+func withDefaults(options Options) Options {
+	if len(options.DateFormats) == 0 {
+		options.DateFormats = DefaultDateFormats
+	}
+	if options.Location == nil {
+		options.Location = time.Local
+	}
+	if options.Delimiter == 0 {
+		options.Delimiter = ','
+	}
+	if options.TagSeparator == "" {
+		options.TagSeparator = ","
+	}
+
+	formats := make([]string, len(options.DateFormats))
+	for i, format := range options.DateFormats {
+		formats[i] = GoLayout(format)
+	}
+	options.DateFormats = formats
+	return options
+}
+
+// Returns the column index of every mapped field
+func resolveColumns(header []string, mapping map[string]string) (map[string]int, error) {
+	indexes := make(map[string]int, len(header))
+	for i, name := range header {
+		indexes[strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))] = i
+	}
+
+	columns := make(map[string]int)
+	for _, field := range Fields {
+		column, mapped := mapping[field]
+		if !mapped {
+			column = field
+		}
+
+		index, ok := indexes[strings.ToLower(column)]
+		if !ok {
+			if mapped {
+				return nil, fmt.Errorf("column %q of %s is not in the header", column, field)
+			}
+			continue
+		}
+		columns[field] = index
+	}
+
+	if _, ok := columns[FieldStart]; !ok {
+		return nil, fmt.Errorf("no start column, map it with --map start=<column>")
+	}
+	_, hasEnd := columns[FieldEnd]
+	_, hasDuration := columns[FieldDuration]
+	if !hasEnd && !hasDuration {
+		return nil, fmt.Errorf("no end or duration column, map one with --map end=<column> or --map duration=<column>")
+	}
+	return columns, nil
+}
+
+func parseRow(record []string, columns map[string]int, options Options) (*store.TimeEntry, error) {
+	get := func(field string) string {
+		index, ok := columns[field]
+		if !ok || index >= len(record) {
+			return ""
+		}
+		return strings.TrimSpace(record[index])
+	}
+
+	timeEntry := &store.TimeEntry{
+		ID:      uuid.New().String(),
+		Project: get(FieldProject),
+		Task:    get(FieldTask),
+		Note:    get(FieldNote),
+		Tags:    splitTags(get(FieldTags), options.TagSeparator),
+	}
+	if timeEntry.Project == "" {
+		timeEntry.Project = options.DefaultProject
+	}
+	if timeEntry.Project == "" {
+		return nil, fmt.Errorf("project is empty")
+	}
+
+	var err error
+	timeEntry.Start, err = parseTime(get(FieldDate), get(FieldStart), options)
+	if err != nil {
+		return nil, fmt.Errorf("invalid start: %v", err)
+	}
+
+	if get(FieldEnd) != "" {
+		timeEntry.End, err = parseTime(get(FieldDate), get(FieldEnd), options)
+		if err != nil {
+			return nil, fmt.Errorf("invalid end: %v", err)
+		}
+		// Times of a separate date column that end after midnight
+		if _, hasDate := columns[FieldDate]; hasDate && timeEntry.End.Before(timeEntry.Start) {
+			timeEntry.End = timeEntry.End.AddDate(0, 0, 1)
+		}
+	} else {
+		duration, err := ParseDuration(get(FieldDuration))
+		if err != nil {
+			return nil, err
+		}
+		timeEntry.End = timeEntry.Start.Add(duration)
+	}
+
+	if !timeEntry.End.After(timeEntry.Start) {
+		return nil, fmt.Errorf("end is not after start")
+	}
+	return timeEntry, nil
+}
+
+func parseTime(date, value string, options Options) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, fmt.Errorf("empty")
+	}
+	if date != "" {
+		value = date + " " + value
+	}
+
+	for _, layout := range options.DateFormats {
+		if t, err := time.ParseInLocation(layout, value, options.Location); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("%q doesn't match the date formats", value)
+}
+
+// Parses durations like 1:30, 1:30:00, 1.5 (hours) or 1h30m
+func ParseDuration(duration string) (time.Duration, error) {
+	if duration == "" {
+		return 0, fmt.Errorf("duration is empty")
+	}
+
+	if strings.Contains(duration, ":") {
+		parts := strings.Split(duration, ":")
+		if len(parts) > 3 {
+			return 0, fmt.Errorf("invalid duration %q", duration)
+		}
+
+		var total time.Duration
+		units := []time.Duration{time.Hour, time.Minute, time.Second}
+		for i, part := range parts {
+			value, err := strconv.Atoi(part)
+			if err != nil || value < 0 {
+				return 0, fmt.Errorf("invalid duration %q", duration)
+			}
+			total += time.Duration(value) * units[i]
+		}
+		return total, nil
+	}
+
+	if hours, err := strconv.ParseFloat(strings.Replace(duration, ",", ".", 1), 64); err == nil {
+		return time.Duration(hours * float64(time.Hour)).Round(time.Second), nil
+	}
+
+	d, err := time.ParseDuration(duration)
+	if err != nil {
+		return 0, fmt.Errorf("invalid duration %q", duration)
+	}
+	return d, nil
+}
+
+var strftime = strings.NewReplacer(
+	"%Y", "2006", "%y", "06", "%m", "01", "%d", "02", "%e", "_2",
+	"%H", "15", "%I", "03", "%M", "04", "%S", "05", "%p", "PM",
+	"%b", "Jan", "%B", "January", "%z", "-0700", "%Z", "MST", "%%", "%",
+)
+
+// Converts strftime formats to Go layouts, Go layouts are returned as they
+// are
+func GoLayout(format string) string {
+	if !strings.Contains(format, "%") {
+		return format
+	}
+	return strftime.Replace(format)
+}
+
+func splitTags(tags, separator string) []string {
+	if tags == "" {
+		return nil
+	}
+
+	result := make([]string, 0)
+	for _, tag := range strings.Split(tags, separator) {
+		if tag = strings.TrimSpace(tag); tag != "" {
+			result = append(result, tag)
+		}
+	}
+	return result
+}
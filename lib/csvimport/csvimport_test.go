@@ -0,0 +1,155 @@
+package csvimport
+
+import (
+	"bytes"
+	"encoding/csv"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gyurkovicsferi/time-tracker/lib/importer"
+	"github.com/gyurkovicsferi/time-tracker/lib/store"
+	"github.com/ostafen/clover/v2"
+)
+
+func testTimeEntries() []*store.TimeEntry {
+	start := time.Date(2026, 10, 1, 9, 0, 0, 0, time.Local)
+	return []*store.TimeEntry{
+		{ID: "1", Project: "Acme", Task: "Development", Start: start, End: start.Add(time.Hour)},
+		{ID: "2", Project: "Acme", Task: "Review", Note: "PR 12, PR 13", Tags: []string{"billable", "remote"}, Start: start.Add(time.Hour), End: start.Add(2 * time.Hour)},
+		{ID: "3", Project: "Acme", Task: "Call", Start: start.Add(23 * time.Hour), End: start.Add(25 * time.Hour)},
+	}
+}
+
+// Writes the time entries like a spreadsheet export: its own column names, the
+// date in a column of its own and the duration instead of the end
+func writeSpreadsheet(t *testing.T, timeEntries []*store.TimeEntry) *bytes.Buffer {
+	var buf bytes.Buffer
+	writer := csv.NewWriter(&buf)
+	writer.Comma = ';'
+	writer.Write([]string{"Day", "From", "Hours", "Client", "Activity", "Comment", "Labels"})
+	for _, timeEntry := range timeEntries {
+		writer.Write([]string{
+			timeEntry.Start.Format("02.01.2006"),
+			timeEntry.Start.Format("15:04"),
+			timeEntry.End.Sub(timeEntry.Start).String(),
+			timeEntry.Project,
+			timeEntry.Task,
+			timeEntry.Note,
+			strings.Join(timeEntry.Tags, "|"),
+		})
+	}
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		t.Fatal(err)
+	}
+	return &buf
+}
+
+func TestRoundTrip(t *testing.T) {
+	db, err := clover.Open(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	s := store.NewStore(db)
+
+	timeEntries := testTimeEntries()
+	s.InsertTimeEntries(timeEntries)
+
+	mapping, err := ParseMapping("date=Day, start=From, duration=Hours, project=Client, task=Activity, note=Comment, tags=Labels")
+	if err != nil {
+		t.Fatal(err)
+	}
+	imported, rowErrors, err := Read(writeSpreadsheet(t, timeEntries), Options{
+		Mapping:      mapping,
+		DateFormats:  []string{"%d.%m.%Y %H:%M"},
+		Location:     time.Local,
+		Delimiter:    ';',
+		TagSeparator: "|",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rowErrors) > 0 {
+		t.Fatalf("unexpected row errors: %v", rowErrors)
+	}
+	if len(imported) != len(timeEntries) {
+		t.Fatalf("read %d time entries, want %d", len(imported), len(timeEntries))
+	}
+
+	for i, timeEntry := range timeEntries {
+		got := imported[i]
+		if got.Project != timeEntry.Project || got.Task != timeEntry.Task || got.Note != timeEntry.Note ||
+			!slices.Equal(got.Tags, timeEntry.Tags) || !got.Start.Equal(timeEntry.Start) || !got.End.Equal(timeEntry.End) {
+			t.Errorf("got %+v, want %+v", got, timeEntry)
+		}
+	}
+
+	for _, candidate := range importer.Plan(s, imported) {
+		if !candidate.Duplicate {
+			t.Errorf("the re-import of %s / %s is not a duplicate", candidate.TimeEntry.Project, candidate.TimeEntry.Task)
+		}
+	}
+}
+
+func TestRowValidation(t *testing.T) {
+	content := strings.Join([]string{
+		"start,end,project,task",
+		"2026-10-01 09:00,2026-10-01 10:00,Acme,Development",
+		"2026-10-01 10:00,2026-10-01 11:00,,Review",
+		"yesterday,2026-10-01 11:00,Acme,Review",
+		"2026-10-01 12:00,2026-10-01 11:00,Acme,Review",
+		"2026-10-01 12:00,,Acme,Review",
+		`2026-10-01 13:00,2026-10-01 14:00,Acme,"Call`,
+	}, "\n")
+
+	timeEntries, rowErrors, err := Read(strings.NewReader(content), Options{Location: time.UTC})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(timeEntries) != 1 || timeEntries[0].Task != "Development" {
+		t.Errorf("got %d time entries, want only Development", len(timeEntries))
+	}
+
+	for i, want := range []struct {
+		line int
+		err  string
+	}{
+		{3, "project is empty"},
+		{4, "invalid start"},
+		{5, "end is not after start"},
+		{6, "duration"},
+		{7, "quote"},
+	} {
+		if i >= len(rowErrors) {
+			t.Errorf("no error for line %d", want.line)
+			continue
+		}
+		got := rowErrors[i]
+		if got.Line != want.line || !strings.Contains(got.Error(), want.err) {
+			t.Errorf("got %q, want line %d with %q", got.Error(), want.line, want.err)
+		}
+	}
+	if len(rowErrors) > 5 {
+		t.Errorf("unexpected row errors: %v", rowErrors[5:])
+	}
+}
+
+func TestMissingColumns(t *testing.T) {
+	for _, test := range []struct {
+		header  string
+		mapping map[string]string
+		err     string
+	}{
+		{"begin,end,project", nil, "no start column"},
+		{"start,project", nil, "no end or duration column"},
+		{"start,end,project", map[string]string{FieldTask: "Activity"}, `column "Activity" of task`},
+	} {
+		_, _, err := Read(strings.NewReader(test.header+"\n"), Options{Mapping: test.mapping})
+		if err == nil || !strings.Contains(err.Error(), test.err) {
+			t.Errorf("%s: got %v, want %q", test.header, err, test.err)
+		}
+	}
+}
@@ -8,7 +8,7 @@ import (
 )
 
 func NewDB() *clover.DB {
-	db, err := clover.Open(GetDefaultPath())
+	db, err := Open(GetDefaultPath())
 	if err != nil {
 		log.Fatal(err)
 	}
@@ -0,0 +1,93 @@
+package db
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/ostafen/clover/v2"
+	"github.com/ostafen/clover/v2/store"
+	"github.com/ostafen/clover/v2/store/bbolt"
+)
+
+// Clover commits every operation in its own transaction. While a transaction
+// of Transaction is running, the operations share it instead, so they are
+// committed together.
+type txStore struct {
+	store.Store
+	// The running transaction, nil outside of one
+	tx store.Tx
+}
+
+func (s *txStore) Begin(update bool) (store.Tx, error) {
+	if s.tx != nil {
+		return sharedTx{s.tx}, nil
+	}
+	return s.Store.Begin(update)
+}
+
+// Committed or rolled back only by the transaction that owns it
+type sharedTx struct {
+	store.Tx
+}
+
+func (sharedTx) Commit() error {
+	return nil
+}
+
+func (sharedTx) Rollback() error {
+	return nil
+}
+
+var (
+	storesMu sync.Mutex
+	stores   = map[*clover.DB]*txStore{}
+)
+
+// Opens the database in dir, with support for Transaction
+func Open(dir string) (*clover.DB, error) {
+	bboltStore, err := bbolt.Open(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	txStore := &txStore{Store: bboltStore}
+	db, err := clover.OpenWithStore(txStore)
+	if err != nil {
+		return nil, err
+	}
+
+	storesMu.Lock()
+	defer storesMu.Unlock()
+	stores[db] = txStore
+	return db, nil
+}
+
+// Runs fn in a single transaction: the changes made by it are committed
+// together if it returns nil, and discarded otherwise. Nested calls join the
+// running transaction. The database must have been opened by Open.
+func Transaction(db *clover.DB, fn func() error) error {
+	storesMu.Lock()
+	txStore, ok := stores[db]
+	storesMu.Unlock()
+	if !ok {
+		return fmt.Errorf("the database does not support transactions, it was not opened by db.Open")
+	}
+
+	if txStore.tx != nil {
+		return fn()
+	}
+
+	tx, err := txStore.Store.Begin(true)
+	if err != nil {
+		return err
+	}
+
+	txStore.tx = tx
+	defer func() { txStore.tx = nil }()
+
+	if err := fn(); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
@@ -0,0 +1,107 @@
+package db_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/gyurkovicsferi/time-tracker/lib/db"
+	"github.com/ostafen/clover/v2"
+	"github.com/ostafen/clover/v2/document"
+	"github.com/ostafen/clover/v2/query"
+)
+
+const collection = "test"
+
+func openDB(t *testing.T) *clover.DB {
+	cdb, err := db.Open(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { cdb.Close() })
+
+	if err := cdb.CreateCollection(collection); err != nil {
+		t.Fatal(err)
+	}
+	return cdb
+}
+
+func insert(t *testing.T, cdb *clover.DB, name string) {
+	doc := document.NewDocument()
+	doc.Set("name", name)
+	if _, err := cdb.InsertOne(collection, doc); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func count(t *testing.T, cdb *clover.DB) int {
+	n, err := cdb.Count(query.NewQuery(collection))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return n
+}
+
+func TestTransactionCommits(t *testing.T) {
+	cdb := openDB(t)
+
+	err := db.Transaction(cdb, func() error {
+		insert(t, cdb, "first")
+		insert(t, cdb, "second")
+		// The operations see the uncommitted changes
+		if n := count(t, cdb); n != 2 {
+			t.Errorf("expected 2 documents in the transaction, got %d", n)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if n := count(t, cdb); n != 2 {
+		t.Errorf("expected 2 documents, got %d", n)
+	}
+}
+
+func TestTransactionRollsBack(t *testing.T) {
+	cdb := openDB(t)
+	insert(t, cdb, "before")
+
+	failed := errors.New("failed")
+	err := db.Transaction(cdb, func() error {
+		insert(t, cdb, "first")
+		return db.Transaction(cdb, func() error {
+			insert(t, cdb, "nested")
+			return failed
+		})
+	})
+	if !errors.Is(err, failed) {
+		t.Fatalf("expected the error of fn, got %v", err)
+	}
+
+	if n := count(t, cdb); n != 1 {
+		t.Errorf("expected only the document inserted before the transaction, got %d", n)
+	}
+
+	// The database is usable after the rollback
+	insert(t, cdb, "after")
+	if n := count(t, cdb); n != 2 {
+		t.Errorf("expected 2 documents, got %d", n)
+	}
+}
+
+func TestTransactionNeedsOpen(t *testing.T) {
+	cdb, err := clover.Open(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cdb.Close()
+
+	called := false
+	err = db.Transaction(cdb, func() error {
+		called = true
+		return nil
+	})
+	if err == nil || called {
+		t.Errorf("expected an error without running fn, got %v", err)
+	}
+}
@@ -0,0 +1,366 @@
+// Package editfile writes time entries as a YAML document to be edited in a
+// text editor and reads the edited document back, validating it against the
+// schema of the time entries.
+//
+// Times are written as RFC 3339 with the zone offset. Fields that are not in
+// the document (e.g. ones added to the time entries later) keep their value.
+package editfile
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	timeentry "github.com/gyurkovicsferi/time-tracker/lib"
+	"github.com/gyurkovicsferi/time-tracker/lib/store"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	fieldID      = "id"
+	fieldProject = "project"
+	fieldTask    = "task"
+	fieldStart   = "start"
+	fieldEnd     = "end"
+	fieldNote    = "note"
+	fieldTags    = "tags"
+)
+
+var (
+	fields         = []string{fieldID, fieldProject, fieldTask, fieldStart, fieldEnd, fieldNote, fieldTags}
+	requiredFields = []string{fieldProject, fieldStart, fieldEnd}
+	// The running time entry has no end
+	runningFields         = []string{fieldID, fieldProject, fieldTask, fieldStart, fieldNote, fieldTags}
+	runningRequiredFields = []string{fieldProject, fieldStart}
+)
+
+// Layouts accepted for the times, the ones without a zone are local
+var timeLayouts = []string{time.RFC3339Nano, "2006-01-02 15:04:05", "2006-01-02 15:04", "2006-01-02T15:04:05", "2006-01-02T15:04"}
+
+type entry struct {
+	ID      string `yaml:"id,omitempty"`
+	Project string `yaml:"project"`
+	Task    string `yaml:"task"`
+	Start   string `yaml:"start"`
+	// Empty for the running time entry
+	End  string   `yaml:"end,omitempty"`
+	Note string   `yaml:"note"`
+	Tags []string `yaml:"tags"`
+}
+
+func fromTimeEntry(timeEntry *store.TimeEntry) *entry {
+	tags := timeEntry.Tags
+	if tags == nil {
+		tags = []string{}
+	}
+
+	return &entry{
+		ID:      timeEntry.ID,
+		Project: timeEntry.Project,
+		Task:    timeEntry.Task,
+		Start:   formatTime(timeEntry.Start),
+		End:     formatTime(timeEntry.End),
+		Note:    timeEntry.Note,
+		Tags:    tags,
+	}
+}
+
+func formatTime(t time.Time) string {
+	return t.Local().Format(time.RFC3339)
+}
+
+// Writes the time entry as a mapping, after the header comment lines
+func FormatOne(timeEntry *store.TimeEntry, header ...string) (string, error) {
+	return format(fromTimeEntry(timeEntry), header)
+}
+
+// Writes the running time entry as a mapping, after the header comment lines
+func FormatRunning(current *store.CurrentTimeEntry, header ...string) (string, error) {
+	e := fromTimeEntry(&store.TimeEntry{
+		ID:      current.ID,
+		Project: current.Project,
+		Task:    current.Task,
+		Note:    current.Note,
+		Tags:    current.Tags,
+		Start:   current.Start,
+	})
+	e.End = ""
+	return format(e, header)
+}
+
+// Writes the time entries as a sequence, after the header comment lines
+func Format(timeEntries []*store.TimeEntry, header ...string) (string, error) {
+	entries := make([]*entry, len(timeEntries))
+	for i, timeEntry := range timeEntries {
+		entries[i] = fromTimeEntry(timeEntry)
+	}
+	return format(entries, header)
+}
+
+func format(value any, header []string) (string, error) {
+	var b bytes.Buffer
+	for _, line := range header {
+		b.WriteString("# " + line + "\n")
+	}
+	if len(header) > 0 {
+		b.WriteString("\n")
+	}
+
+	encoder := yaml.NewEncoder(&b)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(value); err != nil {
+		return "", err
+	}
+	if err := encoder.Close(); err != nil {
+		return "", err
+	}
+	return b.String(), nil
+}
+
+// A decoded entry with the fields that were in the document
+type parsed struct {
+	entry *entry
+	set   map[string]bool
+	line  int
+	start time.Time
+	end   time.Time
+}
+
+// Applies the fields of the document to a copy of the time entry
+func (p *parsed) apply(timeEntry store.TimeEntry) *store.TimeEntry {
+	if p.set[fieldProject] {
+		timeEntry.Project = p.entry.Project
+	}
+	if p.set[fieldTask] {
+		timeEntry.Task = p.entry.Task
+	}
+	if p.set[fieldNote] {
+		timeEntry.Note = p.entry.Note
+	}
+	if p.set[fieldTags] {
+		timeEntry.Tags = p.entry.Tags
+		if len(timeEntry.Tags) == 0 {
+			timeEntry.Tags = nil
+		}
+	}
+	// Unchanged times keep the precision they had
+	if p.entry.Start != formatTime(timeEntry.Start) {
+		timeEntry.Start = p.start
+	}
+	if p.entry.End != formatTime(timeEntry.End) {
+		timeEntry.End = p.end
+	}
+	return &timeEntry
+}
+
+// Reads the document written by FormatOne. The ID must not change.
+func ParseOne(content string, original *store.TimeEntry) (*store.TimeEntry, error) {
+	root, err := parseDocument(content)
+	if err != nil {
+		return nil, err
+	}
+	if root == nil || root.Kind != yaml.MappingNode {
+		return nil, fmt.Errorf("expected the fields of the time entry (%s)", strings.Join(fields, ", "))
+	}
+
+	p, err := parseEntry(root, false)
+	if err != nil {
+		return nil, err
+	}
+	if p.set[fieldID] && p.entry.ID != original.ID {
+		return nil, fmt.Errorf("line %d: the id can't be changed", p.line)
+	}
+
+	return p.apply(*original), nil
+}
+
+// Reads the document written by FormatRunning. The ID must not change.
+func ParseRunning(content string, original *store.CurrentTimeEntry) (*store.CurrentTimeEntry, error) {
+	root, err := parseDocument(content)
+	if err != nil {
+		return nil, err
+	}
+	if root == nil || root.Kind != yaml.MappingNode {
+		return nil, fmt.Errorf("expected the fields of the running time entry (%s)", strings.Join(runningFields, ", "))
+	}
+
+	p, err := parseEntry(root, true)
+	if err != nil {
+		return nil, err
+	}
+	if p.set[fieldID] && p.entry.ID != original.ID {
+		return nil, fmt.Errorf("line %d: the id can't be changed", p.line)
+	}
+
+	edited := p.apply(store.TimeEntry{
+		ID:      original.ID,
+		Project: original.Project,
+		Task:    original.Task,
+		Note:    original.Note,
+		Tags:    original.Tags,
+		Start:   original.Start,
+	})
+	amended := *original
+	amended.Project = edited.Project
+	amended.Task = edited.Task
+	amended.Note = edited.Note
+	amended.Tags = edited.Tags
+	amended.Start = edited.Start
+	return &amended, nil
+}
+
+// Returned by Parse for a document without any entry, not even an empty
+// list. Like an emptied git rebase todo list, it aborts the edit.
+var ErrEmpty = errors.New("the document is empty")
+
+// Reads the document written by Format and compares it with the original
+// time entries. Entries without an id are created, the original entries
+// missing from the document are deleted.
+func Parse(content string, originals []*store.TimeEntry) (*timeentry.Changes, error) {
+	root, err := parseDocument(content)
+	if err != nil {
+		return nil, err
+	}
+
+	if root == nil {
+		return nil, ErrEmpty
+	}
+	if root.Kind != yaml.SequenceNode {
+		return nil, fmt.Errorf("line %d: expected a list of time entries", root.Line)
+	}
+
+	changes := &timeentry.Changes{}
+	byID := make(map[string]*store.TimeEntry, len(originals))
+	for _, original := range originals {
+		byID[original.ID] = original
+	}
+
+	seen := make(map[string]bool)
+	for _, node := range root.Content {
+		if node.Kind != yaml.MappingNode {
+			return nil, fmt.Errorf("line %d: expected the fields of a time entry (%s)", node.Line, strings.Join(fields, ", "))
+		}
+
+		p, err := parseEntry(node, false)
+		if err != nil {
+			return nil, err
+		}
+
+		if p.entry.ID == "" {
+			changes.Create = append(changes.Create, p.apply(store.TimeEntry{ID: uuid.New().String()}))
+			continue
+		}
+
+		original, ok := byID[p.entry.ID]
+		if !ok {
+			return nil, fmt.Errorf("line %d: unknown id %s, remove the id to create a new time entry", p.line, p.entry.ID)
+		}
+		if seen[p.entry.ID] {
+			return nil, fmt.Errorf("line %d: id %s is listed twice", p.line, p.entry.ID)
+		}
+		seen[p.entry.ID] = true
+
+		if edited := p.apply(*original); !timeentry.Equal(original, edited) {
+			changes.Update = append(changes.Update, edited)
+		}
+	}
+
+	for _, original := range originals {
+		if !seen[original.ID] {
+			changes.Delete = append(changes.Delete, original.ID)
+		}
+	}
+
+	return changes, nil
+}
+
+// Returns the root node, nil for an empty document
+func parseDocument(content string) (*yaml.Node, error) {
+	var document yaml.Node
+	if err := yaml.Unmarshal([]byte(content), &document); err != nil {
+		return nil, fmt.Errorf("invalid YAML: %v", strings.TrimPrefix(err.Error(), "yaml: "))
+	}
+	if len(document.Content) == 0 {
+		return nil, nil
+	}
+	return document.Content[0], nil
+}
+
+// Validates the fields of the mapping and decodes them
+func parseEntry(node *yaml.Node, running bool) (*parsed, error) {
+	p := &parsed{entry: &entry{}, set: make(map[string]bool), line: node.Line}
+
+	known, required := fields, requiredFields
+	if running {
+		known, required = runningFields, runningRequiredFields
+	}
+
+	values := make(map[string]*yaml.Node)
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		key, value := node.Content[i], node.Content[i+1]
+		if running && key.Value == fieldEnd {
+			return nil, fmt.Errorf("line %d: the running time entry has no end, stop it to end it", key.Line)
+		}
+		if !slices.Contains(known, key.Value) {
+			return nil, fmt.Errorf("line %d: unknown field %q, expected one of %s", key.Line, key.Value, strings.Join(known, ", "))
+		}
+		if p.set[key.Value] {
+			return nil, fmt.Errorf("line %d: %s is set twice", key.Line, key.Value)
+		}
+		p.set[key.Value] = true
+		values[key.Value] = value
+	}
+
+	for _, field := range required {
+		if !p.set[field] {
+			return nil, fmt.Errorf("line %d: %s is required", node.Line, field)
+		}
+	}
+
+	for field, value := range values {
+		if field == fieldTags {
+			if value.Kind != yaml.SequenceNode && value.Tag != "!!null" {
+				return nil, fmt.Errorf("line %d: tags must be a list, e.g. [meeting, billable]", value.Line)
+			}
+			continue
+		}
+		if value.Kind != yaml.ScalarNode {
+			return nil, fmt.Errorf("line %d: %s must be a single value", value.Line, field)
+		}
+	}
+
+	if err := node.Decode(p.entry); err != nil {
+		return nil, fmt.Errorf("invalid time entry: %v", strings.TrimPrefix(err.Error(), "yaml: "))
+	}
+
+	if strings.TrimSpace(p.entry.Project) == "" {
+		return nil, fmt.Errorf("line %d: project is empty", values[fieldProject].Line)
+	}
+
+	var err error
+	p.start, err = parseTime(p.entry.Start)
+	if err != nil {
+		return nil, fmt.Errorf("line %d: start: %v", values[fieldStart].Line, err)
+	}
+	if !running {
+		p.end, err = parseTime(p.entry.End)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: end: %v", values[fieldEnd].Line, err)
+		}
+	}
+
+	return p, nil
+}
+
+func parseTime(value string) (time.Time, error) {
+	for _, layout := range timeLayouts {
+		if t, err := time.ParseInLocation(layout, value, time.Local); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid time %q, expected e.g. 2006-01-02T15:04:05+02:00 or 2006-01-02 15:04", value)
+}
@@ -0,0 +1,195 @@
+package editfile
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gyurkovicsferi/time-tracker/lib/store"
+)
+
+func testTimeEntries() []*store.TimeEntry {
+	// Stopped time entries have sub-second precision
+	start := time.Date(2026, 10, 1, 9, 0, 0, 123456789, time.Local)
+	return []*store.TimeEntry{
+		{ID: "1", Project: "Acme", Task: "Fix #12", Note: "see # above: yes", Start: start, End: start.Add(time.Hour)},
+		{ID: "2", Project: "Acme", Task: "Review", Tags: []string{"billable"}, Start: start.Add(time.Hour), End: start.Add(2 * time.Hour)},
+	}
+}
+
+func TestParse(t *testing.T) {
+	for _, test := range []struct {
+		name string
+		// Edits the formatted document
+		edit    func(doc string) string
+		err     string
+		created int
+		updated int
+		deleted []string
+	}{
+		{
+			name: "no changes",
+			edit: func(doc string) string { return doc },
+		},
+		{
+			name: "changed task",
+			edit: func(doc string) string {
+				return strings.Replace(doc, "task: Review", "task: Code review", 1)
+			},
+			updated: 1,
+		},
+		{
+			name: "removed entry",
+			edit: func(doc string) string {
+				return doc[:strings.Index(doc, "- id: \"2\"")]
+			},
+			deleted: []string{"2"},
+		},
+		{
+			name: "entry without id",
+			edit: func(doc string) string {
+				return doc + "- project: Acme\n  task: Call\n  start: 2026-10-02 09:00\n  end: 2026-10-02 10:00\n"
+			},
+			created: 1,
+		},
+		{
+			name: "unknown field",
+			edit: func(string) string {
+				return "- project: Acme\n  start: 2026-10-02 09:00\n  end: 2026-10-02 10:00\n  colour: red\n"
+			},
+			err: `line 4: unknown field "colour"`,
+		},
+		{
+			name: "duplicate field",
+			edit: func(string) string {
+				return "- project: Acme\n  start: 2026-10-02 09:00\n  project: Other\n  end: 2026-10-02 10:00\n"
+			},
+			err: "line 3: project is set twice",
+		},
+		{
+			name: "missing field",
+			edit: func(string) string {
+				return "- project: Acme\n  start: 2026-10-02 09:00\n"
+			},
+			err: "line 1: end is required",
+		},
+		{
+			name: "invalid time",
+			edit: func(string) string {
+				return "- project: Acme\n  start: tomorrow\n  end: 2026-10-02 10:00\n"
+			},
+			err: "line 2: start: invalid time",
+		},
+		{
+			name: "unknown id",
+			edit: func(doc string) string {
+				return strings.Replace(doc, `id: "2"`, `id: "3"`, 1)
+			},
+			err: "unknown id 3",
+		},
+		{
+			name: "listed twice",
+			edit: func(doc string) string {
+				entry := doc[strings.Index(doc, "- id: \"2\""):]
+				return doc + entry
+			},
+			err: "id 2 is listed twice",
+		},
+		{
+			name: "comments only",
+			edit: func(doc string) string {
+				return doc[:strings.Index(doc, "- id:")]
+			},
+			err: ErrEmpty.Error(),
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			timeEntries := testTimeEntries()
+			doc, err := Format(timeEntries, "Header", "More # header")
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			changes, err := Parse(test.edit(doc), timeEntries)
+			if test.err != "" {
+				if err == nil || !strings.Contains(err.Error(), test.err) {
+					t.Fatalf("got %v, want %q", err, test.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if len(changes.Create) != test.created || len(changes.Update) != test.updated || strings.Join(changes.Delete, ",") != strings.Join(test.deleted, ",") {
+				t.Errorf("got %d created, %d updated, %v deleted, want %d, %d, %v",
+					len(changes.Create), len(changes.Update), changes.Delete, test.created, test.updated, test.deleted)
+			}
+			for _, updated := range changes.Update {
+				// The unchanged times keep their precision
+				if original := timeEntries[1]; !updated.Start.Equal(original.Start) || !updated.End.Equal(original.End) {
+					t.Errorf("got %s - %s, want %s - %s", updated.Start, updated.End, original.Start, original.End)
+				}
+			}
+		})
+	}
+}
+
+func TestParseEmptyDocument(t *testing.T) {
+	for _, doc := range []string{"", "# only a comment\n\n"} {
+		if _, err := Parse(doc, testTimeEntries()); !errors.Is(err, ErrEmpty) {
+			t.Errorf("%q: got %v, want ErrEmpty", doc, err)
+		}
+	}
+
+	// An empty list is an explicit deletion of everything
+	changes, err := Parse("[]\n", testTimeEntries())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(changes.Delete) != 2 {
+		t.Errorf("got %d deleted, want 2", len(changes.Delete))
+	}
+}
+
+func TestParseOne(t *testing.T) {
+	original := testTimeEntries()[0]
+	doc, err := FormatOne(original)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	edited, err := ParseOne(doc, original)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if edited.Task != "Fix #12" || edited.Note != "see # above: yes" || !edited.Start.Equal(original.Start) || !edited.End.Equal(original.End) {
+		t.Errorf("got %+v, want %+v", edited, original)
+	}
+
+	if _, err := ParseOne(strings.Replace(doc, `id: "1"`, `id: "2"`, 1), original); err == nil || !strings.Contains(err.Error(), "the id can't be changed") {
+		t.Errorf("got %v, want an error for the changed id", err)
+	}
+}
+
+func TestParseRunning(t *testing.T) {
+	start := time.Date(2026, 10, 1, 9, 0, 0, 0, time.Local)
+	current := &store.CurrentTimeEntry{ID: "1", Project: "Acme", Task: "Development", Start: start, Stopped: "0"}
+	doc, err := FormatRunning(current)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	edited, err := ParseRunning(strings.Replace(doc, "task: Development", "task: Review", 1), current)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if edited.Task != "Review" || !edited.Start.Equal(start) || edited.Stopped != "0" {
+		t.Errorf("got %+v", edited)
+	}
+
+	if _, err := ParseRunning(doc+"end: 2026-10-01 10:00\n", current); err == nil || !strings.Contains(err.Error(), "has no end") {
+		t.Errorf("got %v, want an error for the end", err)
+	}
+}
@@ -0,0 +1,131 @@
+package timeentry
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	s "github.com/gyurkovicsferi/time-tracker/lib/store"
+	"github.com/ostafen/clover/v2/query"
+)
+
+// Shorter untracked intervals are not gaps
+const minGap = time.Minute
+
+// Untracked time within the working hours
+type Gap struct {
+	Start time.Time
+	End   time.Time
+	// The time entries ending at the start and starting at the end of the
+	// gap, nil if there is none
+	Before *s.TimeEntry
+	After  *s.TimeEntry
+}
+
+func (g *Gap) Duration() time.Duration {
+	return g.End.Sub(g.Start)
+}
+
+// Returns the untracked intervals of the working hours on the days between
+// from and to. The future and the time of the running entry are not gaps.
+func Gaps(store *s.Store, settings *s.Settings, from, to time.Time) []*Gap {
+	now := time.Now()
+	current := store.GetCurrentTimeEntry()
+
+	gaps := make([]*Gap, 0)
+	for day := s.StartOfDay(from); !day.After(to); day = day.AddDate(0, 0, 1) {
+		start, end, ok := settings.WorkingHours(day)
+		if !ok {
+			continue
+		}
+		if end.After(now) {
+			end = s.StartOfMinute(now)
+		}
+		if current != nil && current.Start.Before(end) {
+			end = current.Start
+		}
+		if !end.After(start) {
+			continue
+		}
+
+		timeEntries := store.GetTimeEntriesQuery(func(q *query.Query) *query.Query {
+			return q.Where(query.Field("start").Lt(end).And(query.Field("end").Gt(start))).
+				Sort(query.SortOption{Field: "start", Direction: 1})
+		})
+
+		gapStart := start
+		var before *s.TimeEntry
+		for _, timeEntry := range timeEntries {
+			if timeEntry.Start.Sub(gapStart) >= minGap {
+				gaps = append(gaps, &Gap{Start: gapStart, End: timeEntry.Start, Before: before, After: timeEntry})
+			}
+			if timeEntry.End.After(gapStart) {
+				gapStart = timeEntry.End
+				before = timeEntry
+			}
+		}
+		if end.Sub(gapStart) >= minGap {
+			gaps = append(gaps, &Gap{Start: gapStart, End: end, Before: before})
+		}
+	}
+
+	// Only neighbours touching the gap can be extended into it
+	for _, gap := range gaps {
+		if gap.Before == nil {
+			gap.Before = endingAt(store, gap.Start)
+		} else if !gap.Before.End.Equal(gap.Start) {
+			gap.Before = nil
+		}
+		if gap.After != nil && !gap.After.Start.Equal(gap.End) {
+			gap.After = nil
+		}
+	}
+	return gaps
+}
+
+func endingAt(store *s.Store, t time.Time) *s.TimeEntry {
+	timeEntries := store.GetTimeEntriesQuery(func(q *query.Query) *query.Query {
+		return q.Where(query.Field("end").Eq(t)).Limit(1)
+	})
+	if len(timeEntries) == 0 {
+		return nil
+	}
+	return timeEntries[0]
+}
+
+// Tracks the gap as a new time entry of the project and task
+func FillGap(store *s.Store, gap *Gap, project, task string) (*s.TimeEntry, error) {
+	timeEntry := &s.TimeEntry{
+		ID:      uuid.New().String(),
+		Project: project,
+		Task:    task,
+		Start:   gap.Start,
+		End:     gap.End,
+	}
+
+	if err := Apply(store, &Changes{Create: []*s.TimeEntry{timeEntry}}); err != nil {
+		return nil, err
+	}
+	return timeEntry, nil
+}
+
+// Extends the time entry before or after the gap over it
+func ExtendIntoGap(store *s.Store, gap *Gap, timeEntry *s.TimeEntry) (*s.TimeEntry, error) {
+	// It may have been extended into the gap on its other side since
+	stored := store.GetTimeEntry(timeEntry.ID)
+	if stored == nil {
+		return nil, fmt.Errorf("time entry %s not found", timeEntry.ID)
+	}
+
+	extended := *stored
+	if timeEntry.End.Equal(gap.Start) {
+		extended.End = gap.End
+	} else {
+		extended.Start = gap.Start
+	}
+
+	if err := Apply(store, &Changes{Update: []*s.TimeEntry{&extended}}); err != nil {
+		return nil, err
+	}
+	return &extended, nil
+}
@@ -0,0 +1,406 @@
+// Package ics reads and writes time entries as iCalendar (RFC 5545) events.
+//
+// Exported events have the project and task as the summary ("Project /
+// Task"), the note as the description, the tags as the categories and the
+// time entry ID as the UID.
+package ics
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/gyurkovicsferi/time-tracker/lib/store"
+)
+
+const (
+	utcLayout      = "20060102T150405Z"
+	localLayout    = "20060102T150405"
+	dateLayout     = "20060102"
+	summarySep     = " / "
+	maxLineLength  = 75
+	productID      = "-//time-entry//time-entry CLI//EN"
+	calendarHeader = "BEGIN:VCALENDAR"
+)
+
+type Attendee struct {
+	Email string
+	Name  string
+}
+
+type Event struct {
+	UID         string
+	Summary     string
+	Description string
+	Categories  []string
+	Attendees   []Attendee
+	Start       time.Time
+	End         time.Time
+	// Date only events, without times
+	AllDay    bool
+	Cancelled bool
+	// Only the first occurrence of recurring events is read
+	Recurring bool
+}
+
+// Whether the event has one of the categories, ignoring case
+func (e *Event) HasCategory(categories ...string) bool {
+	for _, category := range categories {
+		for _, eventCategory := range e.Categories {
+			if strings.EqualFold(category, eventCategory) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Whether one of the attendees (or the organizer) matches, by email or name,
+// ignoring case
+func (e *Event) HasAttendee(attendee string) bool {
+	attendee = strings.ToLower(attendee)
+	for _, eventAttendee := range e.Attendees {
+		if strings.Contains(strings.ToLower(eventAttendee.Email), attendee) || strings.Contains(strings.ToLower(eventAttendee.Name), attendee) {
+			return true
+		}
+	}
+	return false
+}
+
+func FromTimeEntry(timeEntry *store.TimeEntry) *Event {
+	return &Event{
+		UID:         timeEntry.ID,
+		Summary:     timeEntry.Project + summarySep + timeEntry.Task,
+		Description: timeEntry.Note,
+		Categories:  timeEntry.Tags,
+		Start:       timeEntry.Start,
+		End:         timeEntry.End,
+	}
+}
+
+// Summaries written by FromTimeEntry are split back into the project and
+// the task, other events get the default project and the summary as task
+func (e *Event) ToTimeEntry(defaultProject string) *store.TimeEntry {
+	project, task := defaultProject, e.Summary
+	if before, after, ok := strings.Cut(e.Summary, summarySep); ok {
+		project, task = before, after
+	}
+
+	return &store.TimeEntry{
+		ID:      uuid.New().String(),
+		Project: project,
+		Task:    task,
+		Note:    e.Description,
+		Tags:    e.Categories,
+		Start:   e.Start.Local(),
+		End:     e.End.Local(),
+	}
+}
+
+// Writes the events as a calendar
+func Write(w io.Writer, events []*Event) error {
+	writer := bufio.NewWriter(w)
+	stamp := time.Now().UTC().Format(utcLayout)
+
+	lines := []string{calendarHeader, "VERSION:2.0", "PRODID:" + productID, "CALSCALE:GREGORIAN"}
+	for _, event := range events {
+		lines = append(lines,
+			"BEGIN:VEVENT",
+			"UID:"+escape(event.UID),
+			"DTSTAMP:"+stamp,
+			"DTSTART:"+event.Start.UTC().Format(utcLayout),
+			"DTEND:"+event.End.UTC().Format(utcLayout),
+			"SUMMARY:"+escape(event.Summary),
+		)
+		if event.Description != "" {
+			lines = append(lines, "DESCRIPTION:"+escape(event.Description))
+		}
+		if len(event.Categories) > 0 {
+			categories := make([]string, len(event.Categories))
+			for i, category := range event.Categories {
+				categories[i] = escape(category)
+			}
+			lines = append(lines, "CATEGORIES:"+strings.Join(categories, ","))
+		}
+		lines = append(lines, "END:VEVENT")
+	}
+	lines = append(lines, "END:VCALENDAR")
+
+	for _, line := range lines {
+		if _, err := writer.WriteString(fold(line)); err != nil {
+			return err
+		}
+	}
+	return writer.Flush()
+}
+
+func escape(text string) string {
+	return strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\r\n", `\n`, "\n", `\n`).Replace(text)
+}
+
+func unescape(text string) string {
+	return strings.NewReplacer(`\\`, `\`, `\;`, ";", `\,`, ",", `\n`, "\n", `\N`, "\n").Replace(text)
+}
+
+// Splits the line into lines of at most 75 octets, continued with a space,
+// without breaking UTF-8 characters. Lines end with CRLF.
+func fold(line string) string {
+	var folded strings.Builder
+	length := 0
+	for _, r := range line {
+		size := len(string(r))
+		if length+size > maxLineLength {
+			folded.WriteString("\r\n ")
+			length = 1
+		}
+		folded.WriteRune(r)
+		length += size
+	}
+	folded.WriteString("\r\n")
+	return folded.String()
+}
+
+// A content line: NAME;PARAM=value:VALUE
+type property struct {
+	name   string
+	params map[string]string
+	value  string
+}
+
+// Reads the events of a calendar. Times with a TZID are converted from that
+// zone, floating times are read in loc.
+func Read(r io.Reader, loc *time.Location) ([]*Event, error) {
+	lines, err := unfold(r)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(lines) == 0 || !strings.EqualFold(strings.TrimSpace(lines[0].text), calendarHeader) {
+		return nil, fmt.Errorf("not an iCalendar file")
+	}
+
+	events := make([]*Event, 0)
+	var event *Event
+	var duration string
+	// Nested components of events, e.g. VALARM
+	depth := 0
+
+	for _, line := range lines {
+		prop := parseProperty(line.text)
+
+		switch {
+		case prop.name == "BEGIN" && strings.EqualFold(prop.value, "VEVENT"):
+			event, duration, depth = &Event{}, "", 0
+			continue
+		case event == nil:
+			continue
+		case prop.name == "BEGIN":
+			depth++
+			continue
+		case prop.name == "END" && depth > 0:
+			depth--
+			continue
+		case depth > 0:
+			continue
+		case prop.name == "END" && strings.EqualFold(prop.value, "VEVENT"):
+			if event.End.IsZero() {
+				event.End, err = endOf(event, duration)
+				if err != nil {
+					return nil, fmt.Errorf("line %d: %v", line.number, err)
+				}
+			}
+			events = append(events, event)
+			event = nil
+			continue
+		}
+
+		switch prop.name {
+		case "UID":
+			event.UID = prop.value
+		case "SUMMARY":
+			event.Summary = unescape(prop.value)
+		case "DESCRIPTION":
+			event.Description = unescape(prop.value)
+		case "CATEGORIES":
+			event.Categories = append(event.Categories, splitList(prop.value)...)
+		case "ATTENDEE", "ORGANIZER":
+			event.Attendees = append(event.Attendees, Attendee{
+				Email: strings.TrimPrefix(strings.TrimPrefix(prop.value, "mailto:"), "MAILTO:"),
+				Name:  prop.params["CN"],
+			})
+		case "STATUS":
+			event.Cancelled = strings.EqualFold(prop.value, "CANCELLED")
+		case "RRULE", "RDATE":
+			event.Recurring = true
+		case "DTSTART":
+			event.Start, event.AllDay, err = parseTime(prop, loc)
+		case "DTEND":
+			event.End, _, err = parseTime(prop, loc)
+		case "DURATION":
+			duration = prop.value
+		}
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %s: %v", line.number, prop.name, err)
+		}
+	}
+
+	return events, nil
+}
+
+type contentLine struct {
+	number int
+	text   string
+}
+
+// Joins the folded lines, keeping the number of their first line
+func unfold(r io.Reader) ([]contentLine, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+
+	lines := make([]contentLine, 0)
+	for number := 1; scanner.Scan(); number++ {
+		text := strings.TrimRight(scanner.Text(), "\r")
+		if number == 1 {
+			text = strings.TrimPrefix(text, "\ufeff")
+		}
+
+		if (strings.HasPrefix(text, " ") || strings.HasPrefix(text, "\t")) && len(lines) > 0 {
+			lines[len(lines)-1].text += text[1:]
+			continue
+		}
+		if text != "" {
+			lines = append(lines, contentLine{number: number, text: text})
+		}
+	}
+
+	return lines, scanner.Err()
+}
+
+func parseProperty(line string) property {
+	prop := property{params: make(map[string]string)}
+
+	// The value starts at the first colon outside of quoted parameter values
+	quoted := false
+	nameAndParams := line
+	for i, r := range line {
+		if r == '"' {
+			quoted = !quoted
+		}
+		if r == ':' && !quoted {
+			nameAndParams, prop.value = line[:i], line[i+1:]
+			break
+		}
+	}
+
+	parts := strings.Split(nameAndParams, ";")
+	prop.name = strings.ToUpper(parts[0])
+	for _, param := range parts[1:] {
+		key, value, _ := strings.Cut(param, "=")
+		prop.params[strings.ToUpper(key)] = strings.Trim(value, `"`)
+	}
+
+	return prop
+}
+
+// Splits a comma separated list of escaped values
+func splitList(value string) []string {
+	values := make([]string, 0)
+	var current strings.Builder
+	escaped := false
+	for _, r := range value {
+		switch {
+		case escaped:
+			current.WriteRune('\\')
+			current.WriteRune(r)
+			escaped = false
+		case r == '\\':
+			escaped = true
+		case r == ',':
+			values = append(values, strings.TrimSpace(unescape(current.String())))
+			current.Reset()
+		default:
+			current.WriteRune(r)
+		}
+	}
+	values = append(values, strings.TrimSpace(unescape(current.String())))
+
+	result := make([]string, 0, len(values))
+	for _, value := range values {
+		if value != "" {
+			result = append(result, value)
+		}
+	}
+	return result
+}
+
+// Returns the time and whether it is a date only
+func parseTime(prop property, loc *time.Location) (time.Time, bool, error) {
+	if prop.params["VALUE"] == "DATE" || len(prop.value) == len(dateLayout) {
+		t, err := time.ParseInLocation(dateLayout, prop.value, loc)
+		return t, true, err
+	}
+
+	if strings.HasSuffix(prop.value, "Z") {
+		t, err := time.Parse(utcLayout, prop.value)
+		return t, false, err
+	}
+
+	if tzid := prop.params["TZID"]; tzid != "" {
+		tzLoc, err := time.LoadLocation(tzid)
+		if err != nil {
+			return time.Time{}, false, fmt.Errorf("unknown time zone %q", tzid)
+		}
+		loc = tzLoc
+	}
+
+	t, err := time.ParseInLocation(localLayout, prop.value, loc)
+	return t, false, err
+}
+
+// Events without DTEND last DURATION, a day (all-day events) or nothing
+func endOf(event *Event, duration string) (time.Time, error) {
+	if duration != "" {
+		d, err := parseDuration(duration)
+		if err != nil {
+			return time.Time{}, err
+		}
+		return event.Start.Add(d), nil
+	}
+
+	if event.AllDay {
+		return event.Start.AddDate(0, 0, 1), nil
+	}
+	return event.Start, nil
+}
+
+var durationPattern = regexp.MustCompile(`^([+-])?P(?:(\d+)W)?(?:(\d+)D)?(?:T(?:(\d+)H)?(?:(\d+)M)?(?:(\d+)S)?)?$`)
+
+// Parses an RFC 5545 duration, e.g. PT1H30M or P1D
+func parseDuration(duration string) (time.Duration, error) {
+	match := durationPattern.FindStringSubmatch(duration)
+	if match == nil {
+		return 0, fmt.Errorf("invalid duration %q", duration)
+	}
+
+	var total time.Duration
+	for i, unit := range []time.Duration{7 * 24 * time.Hour, 24 * time.Hour, time.Hour, time.Minute, time.Second} {
+		if match[i+2] == "" {
+			continue
+		}
+		value, err := strconv.Atoi(match[i+2])
+		if err != nil {
+			return 0, fmt.Errorf("invalid duration %q", duration)
+		}
+		total += time.Duration(value) * unit
+	}
+
+	if match[1] == "-" {
+		total = -total
+	}
+	return total, nil
+}
@@ -0,0 +1,108 @@
+package ics
+
+import (
+	"bytes"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gyurkovicsferi/time-tracker/lib/importer"
+	"github.com/gyurkovicsferi/time-tracker/lib/store"
+	"github.com/ostafen/clover/v2"
+)
+
+func testTimeEntries() []*store.TimeEntry {
+	start := time.Date(2026, 10, 1, 9, 0, 0, 0, time.Local)
+	return []*store.TimeEntry{
+		{ID: "1", Project: "Acme", Task: "Development", Start: start, End: start.Add(time.Hour)},
+		{ID: "2", Project: "Acme", Task: "Review", Note: "PR 12, PR 13; and a long note that is folded into more lines than one: " + strings.Repeat("é", 40), Tags: []string{"billable", "a,b"}, Start: start.Add(time.Hour), End: start.Add(2 * time.Hour)},
+		{ID: "3", Project: "Acme", Task: "Call", Note: "first line\nsecond line", Start: start.Add(2 * time.Hour), End: start.Add(3 * time.Hour)},
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	db, err := clover.Open(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	s := store.NewStore(db)
+
+	timeEntries := testTimeEntries()
+	s.InsertTimeEntries(timeEntries)
+
+	events := make([]*Event, len(timeEntries))
+	for i, timeEntry := range timeEntries {
+		events[i] = FromTimeEntry(timeEntry)
+	}
+
+	var buf bytes.Buffer
+	if err := Write(&buf, events); err != nil {
+		t.Fatal(err)
+	}
+	for _, line := range strings.Split(buf.String(), "\r\n") {
+		if len(line) > 75 {
+			t.Errorf("line is longer than 75 octets: %q", line)
+		}
+	}
+
+	read, err := Read(&buf, time.Local)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(read) != len(timeEntries) {
+		t.Fatalf("read %d events, want %d", len(read), len(timeEntries))
+	}
+
+	imported := make([]*store.TimeEntry, len(read))
+	for i, event := range read {
+		if event.UID != timeEntries[i].ID {
+			t.Errorf("got UID %s, want %s", event.UID, timeEntries[i].ID)
+		}
+		imported[i] = event.ToTimeEntry("")
+	}
+
+	for i, timeEntry := range timeEntries {
+		got := imported[i]
+		if got.Project != timeEntry.Project || got.Task != timeEntry.Task || got.Note != timeEntry.Note ||
+			!slices.Equal(got.Tags, timeEntry.Tags) || !got.Start.Equal(timeEntry.Start) || !got.End.Equal(timeEntry.End) {
+			t.Errorf("got %+v, want %+v", got, timeEntry)
+		}
+	}
+
+	for _, candidate := range importer.Plan(s, imported) {
+		if !candidate.Duplicate {
+			t.Errorf("the re-import of %s / %s is not a duplicate", candidate.TimeEntry.Project, candidate.TimeEntry.Task)
+		}
+	}
+}
+
+func TestForeignEvents(t *testing.T) {
+	calendar := strings.Join([]string{
+		"BEGIN:VCALENDAR",
+		"BEGIN:VEVENT",
+		"UID:meeting",
+		"SUMMARY:Weekly",
+		"DTSTART;TZID=Europe/Budapest:20261001T100000",
+		"DURATION:PT1H30M",
+		"END:VEVENT",
+		"END:VCALENDAR",
+	}, "\r\n")
+
+	events, err := Read(strings.NewReader(calendar), time.UTC)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(events) != 1 {
+		t.Fatalf("read %d events, want 1", len(events))
+	}
+
+	got := events[0].ToTimeEntry("Meetings")
+	if got.Project != "Meetings" || got.Task != "Weekly" {
+		t.Errorf("got %s / %s, want Meetings / Weekly", got.Project, got.Task)
+	}
+	if want := time.Date(2026, 10, 1, 8, 0, 0, 0, time.UTC); !got.Start.Equal(want) || got.End.Sub(got.Start) != 90*time.Minute {
+		t.Errorf("got %s - %s, want 90 minutes from %s", got.Start, got.End, want)
+	}
+}
@@ -0,0 +1,115 @@
+// Package importer holds what the file imports have in common: skipping
+// entries that already exist and inserting the rest at once.
+package importer
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/gyurkovicsferi/time-tracker/lib/store"
+	"github.com/ostafen/clover/v2/query"
+)
+
+// Used for imported entries without a project
+const NoProject = "No project"
+
+type Candidate struct {
+	TimeEntry *store.TimeEntry
+	// An entry with the same content exists already, or appears earlier in
+	// the same import
+	Duplicate bool
+}
+
+// Identifies a time entry by its content, so importing the same data twice
+// (e.g. an export of our own entries) doesn't duplicate it. Times are
+// compared with second precision, as most formats don't keep more.
+func ContentHash(timeEntry *store.TimeEntry) string {
+	tags := slices.Clone(timeEntry.Tags)
+	slices.Sort(tags)
+
+	hash := sha256.New()
+	for _, field := range []string{
+		timeEntry.Project,
+		timeEntry.Task,
+		timeEntry.Note,
+		strings.Join(tags, ","),
+		timeEntry.Start.UTC().Truncate(time.Second).Format(time.RFC3339),
+		timeEntry.End.UTC().Truncate(time.Second).Format(time.RFC3339),
+	} {
+		hash.Write([]byte(field))
+		hash.Write([]byte{0})
+	}
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+// Marks the entries that already exist in the store
+func Plan(s *store.Store, timeEntries []*store.TimeEntry) []*Candidate {
+	candidates := make([]*Candidate, len(timeEntries))
+	if len(timeEntries) == 0 {
+		return candidates
+	}
+
+	start, end := timeEntries[0].Start, timeEntries[0].Start
+	for _, timeEntry := range timeEntries {
+		start = minTime(start, timeEntry.Start)
+		end = maxTime(end, timeEntry.Start)
+	}
+
+	existing := s.GetTimeEntriesQuery(func(q *query.Query) *query.Query {
+		return q.Where(query.Field("start").GtEq(start.Add(-time.Second)).And(query.Field("start").LtEq(end.Add(time.Second))))
+	})
+
+	seen := make(map[string]bool, len(existing)+len(timeEntries))
+	for _, timeEntry := range existing {
+		seen[ContentHash(timeEntry)] = true
+
+		// Formats with a single description, like Toggl, read a note without
+		// a task back as the task
+		if timeEntry.Task == "" && timeEntry.Note != "" {
+			asTask := *timeEntry
+			asTask.Task, asTask.Note = timeEntry.Note, ""
+			seen[ContentHash(&asTask)] = true
+		}
+	}
+
+	for i, timeEntry := range timeEntries {
+		hash := ContentHash(timeEntry)
+		candidates[i] = &Candidate{TimeEntry: timeEntry, Duplicate: seen[hash]}
+		seen[hash] = true
+	}
+
+	return candidates
+}
+
+// Inserts the new candidates in a single transaction. Returns the inserted
+// entries.
+func Apply(s *store.Store, candidates []*Candidate) []*store.TimeEntry {
+	timeEntries := make([]*store.TimeEntry, 0, len(candidates))
+	for _, candidate := range candidates {
+		if !candidate.Duplicate {
+			timeEntries = append(timeEntries, candidate.TimeEntry)
+		}
+	}
+
+	if len(timeEntries) > 0 {
+		s.InsertTimeEntries(timeEntries)
+	}
+	return timeEntries
+}
+
+func minTime(a, b time.Time) time.Time {
+	if b.Before(a) {
+		return b
+	}
+	return a
+}
+
+func maxTime(a, b time.Time) time.Time {
+	if b.After(a) {
+		return b
+	}
+	return a
+}
@@ -0,0 +1,118 @@
+package jira
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/gyurkovicsferi/time-tracker/lib/apiclient"
+)
+
+// The format of the worklog start, e.g. 2026-10-01T09:00:00.000+0200
+const startLayout = "2006-01-02T15:04:05.000-0700"
+
+// A client of the Jira REST API (v2, where worklog comments are plain text)
+type JiraAPI struct {
+	client *apiclient.Client
+}
+
+// Jira Cloud authenticates with the email and an API token, Jira Server and
+// Data Center with a personal access token only: the email is empty then
+func NewJiraAPI(baseURL, email, token string, options ...apiclient.Option) *JiraAPI {
+	authenticate := func(req *http.Request) {
+		if email != "" {
+			req.SetBasicAuth(email, token)
+		} else {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+	}
+
+	return &JiraAPI{client: apiclient.New(baseURL, authenticate, options...)}
+}
+
+func NewJiraAPIFromConfig(config *JiraConfig, options ...apiclient.Option) *JiraAPI {
+	return NewJiraAPI(config.BaseURL, config.Email, config.APIToken, options...)
+}
+
+type JiraUser struct {
+	AccountID    string `json:"accountId"`
+	Name         string `json:"name"`
+	DisplayName  string `json:"displayName"`
+	EmailAddress string `json:"emailAddress"`
+}
+
+type JiraWorklogPayload struct {
+	Started          string `json:"started"`
+	TimeSpentSeconds int64  `json:"timeSpentSeconds"`
+	Comment          string `json:"comment,omitempty"`
+}
+
+type JiraWorklog struct {
+	ID               string `json:"id"`
+	IssueID          string `json:"issueId"`
+	Started          string `json:"started"`
+	TimeSpentSeconds int64  `json:"timeSpentSeconds"`
+	Comment          string `json:"comment"`
+}
+
+func worklogPath(issueKey string, worklogID ...string) string {
+	path := "/rest/api/2/issue/" + url.PathEscape(issueKey) + "/worklog"
+	for _, id := range worklogID {
+		path += "/" + url.PathEscape(id)
+	}
+	return path
+}
+
+func (c *JiraAPI) GetCurrentUser(ctx context.Context) (*JiraUser, error) {
+	req, err := c.client.NewRequest(ctx, "GET", "/rest/api/2/myself", nil)
+	if err != nil {
+		return nil, err
+	}
+
+	user := &JiraUser{}
+	if err := c.client.Do(req, user); err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+// Returns the id of the new worklog
+func (c *JiraAPI) AddWorklog(ctx context.Context, issueKey string, worklog JiraWorklogPayload) (string, error) {
+	req, err := c.client.NewRequest(ctx, "POST", worklogPath(issueKey), worklog)
+	if err != nil {
+		return "", err
+	}
+
+	result := &JiraWorklog{}
+	if err := c.client.Do(req, result); err != nil {
+		return "", err
+	}
+	return result.ID, nil
+}
+
+func (c *JiraAPI) UpdateWorklog(ctx context.Context, issueKey, worklogID string, worklog JiraWorklogPayload) error {
+	req, err := c.client.NewRequest(ctx, "PUT", worklogPath(issueKey, worklogID), worklog)
+	if err != nil {
+		return err
+	}
+
+	return c.client.Do(req, nil)
+}
+
+func (c *JiraAPI) DeleteWorklog(ctx context.Context, issueKey, worklogID string) error {
+	req, err := c.client.NewRequest(ctx, "DELETE", worklogPath(issueKey, worklogID), nil)
+	if err != nil {
+		return err
+	}
+
+	return c.client.Do(req, nil)
+}
+
+func FormatStarted(t time.Time) string {
+	return t.Format(startLayout)
+}
+
+func ParseStarted(started string) (time.Time, error) {
+	return time.Parse(startLayout, started)
+}
@@ -0,0 +1,166 @@
+package jira
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/gyurkovicsferi/time-tracker/lib/apiclient"
+	"github.com/gyurkovicsferi/time-tracker/lib/provider"
+	"github.com/gyurkovicsferi/time-tracker/lib/store"
+	"github.com/ostafen/clover/v2"
+)
+
+const ProviderName = "jira"
+
+// Jira doesn't accept worklogs shorter than a minute
+const minWorklog = time.Minute
+
+// Matches issue keys like PROJ-123
+var issueKeyPattern = regexp.MustCompile(`\b[A-Z][A-Z0-9_]+-[1-9][0-9]*\b`)
+
+func init() {
+	provider.Register(ProviderName, NewProviderFromDB)
+}
+
+// Jira as a sync provider: the time entries of Jira issues are logged as
+// worklogs. Entries without an issue key are skipped.
+type JiraProvider struct {
+	api *JiraAPI
+}
+
+func NewProvider(api *JiraAPI) *JiraProvider {
+	return &JiraProvider{api: api}
+}
+
+// Creates the provider from the stored configuration
+func NewProviderFromDB(db *clover.DB, options provider.Options) (provider.Provider, error) {
+	config, err := NewJiraStore(db).GetJiraConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	config.APIToken, err = ResolveAPIToken(config, options.Passphrase)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewProvider(NewJiraAPIFromConfig(config)), nil
+}
+
+// Returns the first issue key in the task, or in the project if the task has
+// none. Empty if neither has one.
+func IssueKey(project, task string) string {
+	if key := issueKeyPattern.FindString(task); key != "" {
+		return key
+	}
+	return issueKeyPattern.FindString(project)
+}
+
+// The remote ID of a worklog is the issue key and the worklog id, as the
+// worklog API needs both
+func remoteID(issueKey, worklogID string) string {
+	return issueKey + "/" + worklogID
+}
+
+func splitRemoteID(remoteID string) (string, string, error) {
+	issueKey, worklogID, ok := strings.Cut(remoteID, "/")
+	if !ok {
+		return "", "", fmt.Errorf("invalid jira worklog id %q", remoteID)
+	}
+	return issueKey, worklogID, nil
+}
+
+func (p *JiraProvider) Name() string {
+	return ProviderName
+}
+
+func (p *JiraProvider) HealthCheck(ctx context.Context) error {
+	_, err := p.api.GetCurrentUser(ctx)
+	return err
+}
+
+func (p *JiraProvider) Push(ctx context.Context, timeEntry *store.TimeEntry, remote string, mapping *provider.ProjectMapping) (string, error) {
+	issueKey := mapping.RemoteTaskID
+	worklog := worklogPayload(timeEntry)
+
+	if remote != "" {
+		remoteIssueKey, worklogID, err := splitRemoteID(remote)
+		if err != nil {
+			return "", err
+		}
+
+		if remoteIssueKey == issueKey && worklog.TimeSpentSeconds >= int64(minWorklog/time.Second) {
+			return remote, p.api.UpdateWorklog(ctx, issueKey, worklogID, worklog)
+		}
+
+		// Moved to another issue (or no issue at all): logged again below
+		if err := p.Delete(ctx, remote); err != nil {
+			return "", err
+		}
+	}
+
+	if issueKey == "" {
+		return "", fmt.Errorf("%w: no jira issue key in %q", provider.ErrSkipped, timeEntry.Task)
+	}
+	if worklog.TimeSpentSeconds < int64(minWorklog/time.Second) {
+		return "", fmt.Errorf("%w: shorter than a minute", provider.ErrSkipped)
+	}
+
+	worklogID, err := p.api.AddWorklog(ctx, issueKey, worklog)
+	if err != nil {
+		return "", err
+	}
+	return remoteID(issueKey, worklogID), nil
+}
+
+// Deleting a worklog that is already gone from Jira succeeds
+func (p *JiraProvider) Delete(ctx context.Context, remote string) error {
+	issueKey, worklogID, err := splitRemoteID(remote)
+	if err != nil {
+		return err
+	}
+
+	err = p.api.DeleteWorklog(ctx, issueKey, worklogID)
+	if apiclient.IsStatus(err, http.StatusNotFound) {
+		return nil
+	}
+	return err
+}
+
+// Worklogs are only pushed to Jira
+func (p *JiraProvider) Pull(ctx context.Context, start, end time.Time) ([]*provider.RemoteTimeEntry, error) {
+	return nil, fmt.Errorf("pulling from jira: %w", errors.ErrUnsupported)
+}
+
+// Maps the time entry to the issue in its task (or project) name. The project
+// of the issue key is kept as the remote project.
+func (p *JiraProvider) MapProject(ctx context.Context, project, task string) (*provider.ProjectMapping, error) {
+	mapping := &provider.ProjectMapping{}
+
+	issueKey := IssueKey(project, task)
+	if issueKey != "" {
+		mapping.RemoteProjectID, _, _ = strings.Cut(issueKey, "-")
+		mapping.RemoteTaskID = issueKey
+	}
+
+	return mapping, nil
+}
+
+// The comment is the note, or the project and task without a note
+func worklogPayload(timeEntry *store.TimeEntry) JiraWorklogPayload {
+	comment := timeEntry.Note
+	if comment == "" {
+		comment = fmt.Sprintf("%s - %s", timeEntry.Project, timeEntry.Task)
+	}
+
+	return JiraWorklogPayload{
+		Started:          FormatStarted(timeEntry.Start),
+		TimeSpentSeconds: int64(timeEntry.End.Sub(timeEntry.Start).Round(time.Second) / time.Second),
+		Comment:          comment,
+	}
+}
@@ -0,0 +1,178 @@
+package jira_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/gyurkovicsferi/time-tracker/lib/apiclient"
+	"github.com/gyurkovicsferi/time-tracker/lib/jira"
+	"github.com/gyurkovicsferi/time-tracker/lib/jira/jiratest"
+	"github.com/gyurkovicsferi/time-tracker/lib/provider"
+	"github.com/gyurkovicsferi/time-tracker/lib/store"
+	"github.com/ostafen/clover/v2"
+)
+
+type fixture struct {
+	server *jiratest.Server
+	store  *store.Store
+	ledger *provider.LedgerStore
+	syncer *provider.Syncer
+}
+
+func newFixture(t *testing.T) *fixture {
+	t.Helper()
+
+	db, err := clover.Open(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	server := jiratest.NewServer()
+	t.Cleanup(server.Close)
+
+	f := &fixture{
+		server: server,
+		store:  store.NewStore(db),
+		ledger: provider.NewLedgerStore(db),
+	}
+	f.syncer = provider.NewSyncer(f.store, f.ledger, jira.NewProvider(server.API()))
+	return f
+}
+
+func (f *fixture) insert(project, task string, start time.Time, duration time.Duration) *store.TimeEntry {
+	timeEntry := &store.TimeEntry{
+		ID:      uuid.New().String(),
+		Project: project,
+		Task:    task,
+		Start:   start,
+		End:     start.Add(duration),
+	}
+	f.store.InsertTimeEntry(timeEntry)
+	return timeEntry
+}
+
+func (f *fixture) push(t *testing.T) map[provider.SyncAction]int {
+	t.Helper()
+
+	results, err := f.syncer.Push(context.Background(), day, day.AddDate(0, 0, 1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	summary := provider.Summarize(results)
+	if summary.Failed != 0 {
+		t.Fatalf("got %d failures: %+v", summary.Failed, results)
+	}
+	return summary.Succeeded
+}
+
+var day = time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+
+func TestPushLogsWorklogsOfIssues(t *testing.T) {
+	f := newFixture(t)
+	f.server.AddIssue("PROJ-1")
+
+	f.insert("Acme", "PROJ-1 Login page", day.Add(9*time.Hour), time.Hour)
+	f.insert("Acme", "Meeting", day.Add(11*time.Hour), time.Hour)
+	f.insert("Acme", "PROJ-1 Typo", day.Add(13*time.Hour), 30*time.Second)
+
+	actions := f.push(t)
+	if actions[provider.SyncActionCreated] != 1 || actions[provider.SyncActionSkipped] != 2 {
+		t.Fatalf("got actions %v, want 1 created and 2 skipped", actions)
+	}
+
+	worklogs := f.server.Worklogs("PROJ-1")
+	if len(worklogs) != 1 {
+		t.Fatalf("got %d worklogs, want 1", len(worklogs))
+	}
+	if worklogs[0].TimeSpentSeconds != 3600 || worklogs[0].Comment != "Acme - PROJ-1 Login page" {
+		t.Errorf("got worklog %+v", worklogs[0])
+	}
+}
+
+func TestPushMovesWorklogsToTheNewIssue(t *testing.T) {
+	f := newFixture(t)
+	f.server.AddIssue("PROJ-1")
+	f.server.AddIssue("PROJ-2")
+
+	timeEntry := f.insert("Acme", "PROJ-1 Login page", day.Add(9*time.Hour), time.Hour)
+	f.push(t)
+
+	timeEntry.End = timeEntry.End.Add(30 * time.Minute)
+	f.store.UpdateTimeEntry(timeEntry)
+	if actions := f.push(t); actions[provider.SyncActionUpdated] != 1 {
+		t.Fatalf("got actions %v, want 1 updated", actions)
+	}
+	if worklogs := f.server.Worklogs("PROJ-1"); len(worklogs) != 1 || worklogs[0].TimeSpentSeconds != 5400 {
+		t.Fatalf("the worklog was not updated: %+v", worklogs)
+	}
+
+	timeEntry.Task = "PROJ-2 Login page"
+	f.store.UpdateTimeEntry(timeEntry)
+	f.push(t)
+	if got := len(f.server.Worklogs("PROJ-1")); got != 0 {
+		t.Errorf("got %d worklogs on the old issue, want 0", got)
+	}
+	if got := len(f.server.Worklogs("PROJ-2")); got != 1 {
+		t.Errorf("got %d worklogs on the new issue, want 1", got)
+	}
+}
+
+func TestPushDeletesWorklogsOfDeletedTimeEntries(t *testing.T) {
+	f := newFixture(t)
+	f.server.AddIssue("PROJ-1")
+
+	timeEntry := f.insert("Acme", "PROJ-1 Login page", day.Add(9*time.Hour), time.Hour)
+	f.push(t)
+
+	f.store.TrashTimeEntry(timeEntry.ID)
+	if err := f.ledger.MarkDeleted(timeEntry.ID); err != nil {
+		t.Fatal(err)
+	}
+	if actions := f.push(t); actions[provider.SyncActionDeleted] != 1 {
+		t.Fatalf("got actions %v, want 1 deleted", actions)
+	}
+	if got := len(f.server.Worklogs("PROJ-1")); got != 0 {
+		t.Errorf("got %d worklogs, want 0", got)
+	}
+}
+
+func TestInvalidCredentials(t *testing.T) {
+	server := jiratest.NewServer()
+	defer server.Close()
+
+	api := jira.NewJiraAPI(server.URL, jiratest.Email, "wrong-token", apiclient.WithHTTPClient(server.Client()))
+	if _, err := api.GetCurrentUser(context.Background()); !apiclient.IsStatus(err, http.StatusUnauthorized) {
+		t.Errorf("got error %v, want status 401", err)
+	}
+}
+
+func TestResolveAPIToken(t *testing.T) {
+	t.Setenv(jira.APITokenEnv, "")
+	t.Setenv(jira.APITokenFileEnv, "")
+	t.Setenv(apiclient.PassphraseEnv, "")
+
+	if _, err := jira.ResolveAPIToken(&jira.JiraConfig{}, nil); !errors.Is(err, provider.ErrNotConfigured) {
+		t.Errorf("got %v without a token, want an error wrapping provider.ErrNotConfigured", err)
+	}
+
+	encrypted, err := apiclient.EncryptSecret("secret-token", "passphrase")
+	if err != nil {
+		t.Fatal(err)
+	}
+	config := &jira.JiraConfig{EncryptedAPIToken: encrypted}
+
+	token, err := jira.ResolveAPIToken(config, func() (string, error) { return "passphrase", nil })
+	if err != nil || token != "secret-token" {
+		t.Errorf("got %q, %v, want the decrypted token", token, err)
+	}
+
+	t.Setenv(jira.APITokenEnv, "env-token")
+	if token, err := jira.ResolveAPIToken(config, nil); err != nil || token != "env-token" {
+		t.Errorf("got %q, %v, want the token of %s", token, err, jira.APITokenEnv)
+	}
+}
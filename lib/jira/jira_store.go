@@ -0,0 +1,119 @@
+package jira
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/gyurkovicsferi/time-tracker/lib/apiclient"
+	"github.com/gyurkovicsferi/time-tracker/lib/provider"
+	"github.com/ostafen/clover/v2"
+	"github.com/ostafen/clover/v2/document"
+	"github.com/ostafen/clover/v2/query"
+)
+
+const (
+	JiraConfigCollection = "jira_config"
+
+	// Takes precedence over the stored API token
+	APITokenEnv = "JIRA_API_TOKEN"
+	// Path of a file containing the API token, takes precedence over the
+	// stored one
+	APITokenFileEnv = "JIRA_API_TOKEN_FILE"
+)
+
+type JiraConfig struct {
+	ID string `clover:"id"`
+	// E.g. https://example.atlassian.net
+	BaseURL string `clover:"base_url"`
+	// Empty when the API token is a personal access token
+	Email string `clover:"email"`
+	// Plaintext, only set by older versions. New configurations use
+	// --encrypt or --api-token-file. Use ResolveAPIToken to get the token.
+	APIToken string `clover:"api_token"`
+	// Read on every run instead of storing the token
+	APITokenFile string `clover:"api_token_file"`
+	// Encrypted with a passphrase, see apiclient.EncryptSecret
+	EncryptedAPIToken string `clover:"encrypted_api_token"`
+}
+
+var ErrNotConfigured = fmt.Errorf("jira is %w, run: time-entry jira config set", provider.ErrNotConfigured)
+
+var ErrNoAPIToken = fmt.Errorf("the Jira API token is %w, set %s or run: time-entry jira config set", provider.ErrNotConfigured, APITokenEnv)
+
+type JiraStore struct {
+	db *clover.DB
+}
+
+func NewJiraStore(cloverDB *clover.DB) *JiraStore {
+	store := &JiraStore{db: cloverDB}
+	store.createConfigCollectionIfNotExists()
+	return store
+}
+
+func (s *JiraStore) createConfigCollectionIfNotExists() {
+	hasCollection, err := s.db.HasCollection(JiraConfigCollection)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if !hasCollection {
+		err = s.db.CreateCollection(JiraConfigCollection)
+		if err != nil {
+			log.Fatal(err)
+		}
+	}
+}
+
+func (s *JiraStore) GetJiraConfig() (*JiraConfig, error) {
+	doc, err := s.db.FindFirst(query.NewQuery(JiraConfigCollection))
+	if err != nil {
+		return nil, err
+	}
+
+	if doc == nil {
+		return nil, ErrNotConfigured
+	}
+
+	config := &JiraConfig{}
+	if err := doc.Unmarshal(config); err != nil {
+		return nil, err
+	}
+	return config, nil
+}
+
+// Replaces the existing configuration
+func (s *JiraStore) InsertJiraConfig(config *JiraConfig) error {
+	err := s.DeleteJiraConfig()
+	if err != nil {
+		return err
+	}
+
+	return s.db.Insert(JiraConfigCollection, document.NewDocumentOf(config))
+}
+
+func (s *JiraStore) DeleteJiraConfig() error {
+	return s.db.Delete(query.NewQuery(JiraConfigCollection))
+}
+
+// The sources of the API token, in order: JIRA_API_TOKEN,
+// JIRA_API_TOKEN_FILE, the configured token file, the encrypted token and
+// finally the plaintext token of older configurations
+func APITokenSources(config *JiraConfig) apiclient.SecretSources {
+	return apiclient.SecretSources{
+		Env:       APITokenEnv,
+		FileEnv:   APITokenFileEnv,
+		File:      config.APITokenFile,
+		Encrypted: config.EncryptedAPIToken,
+		Plaintext: config.APIToken,
+	}
+}
+
+// Returns the API token of the configuration from the first of its sources
+// that has one
+func ResolveAPIToken(config *JiraConfig, passphrase apiclient.PassphraseFunc) (string, error) {
+	token, err := apiclient.ResolveSecret("Jira API token", APITokenSources(config), passphrase)
+	if err == nil && token == "" {
+		return "", ErrNoAPIToken
+	}
+	return token, err
+}
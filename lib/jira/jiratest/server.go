@@ -0,0 +1,232 @@
+// Package jiratest provides an in-memory fake of the Jira worklog API, so the
+// Jira sync can be exercised without network access.
+package jiratest
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"sync"
+
+	"github.com/gyurkovicsferi/time-tracker/lib/apiclient"
+	"github.com/gyurkovicsferi/time-tracker/lib/jira"
+)
+
+const (
+	Email    = "test@example.com"
+	APIToken = "test-api-token"
+)
+
+type Server struct {
+	*httptest.Server
+
+	mu       sync.Mutex
+	nextID   int
+	issues   map[string]bool
+	worklogs map[string][]*jira.JiraWorklog
+	requests []*http.Request
+}
+
+// Starts a fake Jira server accepting Email and APIToken with basic auth.
+// Only the issues added with AddIssue exist. The server is closed by calling
+// Close.
+func NewServer() *Server {
+	s := &Server{
+		issues:   make(map[string]bool),
+		worklogs: make(map[string][]*jira.JiraWorklog),
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /rest/api/2/myself", s.getUser)
+	mux.HandleFunc("GET /rest/api/2/issue/{issueKey}/worklog", s.listWorklogs)
+	mux.HandleFunc("POST /rest/api/2/issue/{issueKey}/worklog", s.addWorklog)
+	mux.HandleFunc("PUT /rest/api/2/issue/{issueKey}/worklog/{id}", s.updateWorklog)
+	mux.HandleFunc("DELETE /rest/api/2/issue/{issueKey}/worklog/{id}", s.deleteWorklog)
+
+	s.Server = httptest.NewServer(s.authenticate(mux))
+	return s
+}
+
+// Returns a client configured against the fake server
+func (s *Server) API(options ...apiclient.Option) *jira.JiraAPI {
+	options = append([]apiclient.Option{apiclient.WithHTTPClient(s.Client())}, options...)
+	return jira.NewJiraAPI(s.URL, Email, APIToken, options...)
+}
+
+func (s *Server) AddIssue(issueKey string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.issues[issueKey] = true
+}
+
+// Returns a copy of the worklogs of the issue
+func (s *Server) Worklogs(issueKey string) []*jira.JiraWorklog {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return slices.Clone(s.worklogs[issueKey])
+}
+
+// Returns the requests received so far
+func (s *Server) Requests() []*http.Request {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return slices.Clone(s.requests)
+}
+
+func (s *Server) authenticate(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		s.requests = append(s.requests, r)
+		s.mu.Unlock()
+
+		email, token, ok := r.BasicAuth()
+		if !ok || email != Email || token != APIToken {
+			writeError(w, http.StatusUnauthorized, "invalid credentials")
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// Must be called with the lock held
+func (s *Server) checkIssue(w http.ResponseWriter, r *http.Request) bool {
+	if !s.issues[r.PathValue("issueKey")] {
+		writeError(w, http.StatusNotFound, "Issue does not exist or you do not have permission to see it.")
+		return false
+	}
+	return true
+}
+
+func (s *Server) getUser(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, jira.JiraUser{
+		AccountID:    "test-account",
+		DisplayName:  "Test User",
+		EmailAddress: Email,
+	})
+}
+
+func (s *Server) listWorklogs(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.checkIssue(w, r) {
+		return
+	}
+
+	worklogs := s.worklogs[r.PathValue("issueKey")]
+	writeJSON(w, http.StatusOK, map[string]any{
+		"startAt":    0,
+		"maxResults": len(worklogs),
+		"total":      len(worklogs),
+		"worklogs":   worklogs,
+	})
+}
+
+func (s *Server) addWorklog(w http.ResponseWriter, r *http.Request) {
+	worklog, err := decodeWorklog(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.checkIssue(w, r) {
+		return
+	}
+
+	issueKey := r.PathValue("issueKey")
+	s.nextID++
+	worklog.ID = fmt.Sprintf("%d", 10000+s.nextID)
+	worklog.IssueID = issueKey
+	s.worklogs[issueKey] = append(s.worklogs[issueKey], worklog)
+	writeJSON(w, http.StatusCreated, worklog)
+}
+
+func (s *Server) updateWorklog(w http.ResponseWriter, r *http.Request) {
+	worklog, err := decodeWorklog(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.checkIssue(w, r) {
+		return
+	}
+
+	issueKey := r.PathValue("issueKey")
+	index := s.worklogIndex(issueKey, r.PathValue("id"))
+	if index < 0 {
+		writeError(w, http.StatusNotFound, "Cannot find worklog")
+		return
+	}
+
+	worklog.ID = r.PathValue("id")
+	worklog.IssueID = issueKey
+	s.worklogs[issueKey][index] = worklog
+	writeJSON(w, http.StatusOK, worklog)
+}
+
+func (s *Server) deleteWorklog(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.checkIssue(w, r) {
+		return
+	}
+
+	issueKey := r.PathValue("issueKey")
+	index := s.worklogIndex(issueKey, r.PathValue("id"))
+	if index < 0 {
+		writeError(w, http.StatusNotFound, "Cannot find worklog")
+		return
+	}
+
+	s.worklogs[issueKey] = slices.Delete(s.worklogs[issueKey], index, index+1)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) worklogIndex(issueKey, id string) int {
+	return slices.IndexFunc(s.worklogs[issueKey], func(worklog *jira.JiraWorklog) bool {
+		return worklog.ID == id
+	})
+}
+
+func decodeWorklog(r *http.Request) (*jira.JiraWorklog, error) {
+	payload := jira.JiraWorklogPayload{}
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		return nil, err
+	}
+
+	if _, err := jira.ParseStarted(payload.Started); err != nil {
+		return nil, fmt.Errorf("invalid started")
+	}
+	if payload.TimeSpentSeconds < 60 {
+		return nil, fmt.Errorf("worklog must not be shorter than a minute")
+	}
+
+	return &jira.JiraWorklog{
+		Started:          payload.Started,
+		TimeSpentSeconds: payload.TimeSpentSeconds,
+		Comment:          payload.Comment,
+	}, nil
+}
+
+func writeJSON(w http.ResponseWriter, status int, body any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(body)
+}
+
+func writeError(w http.ResponseWriter, status int, message string) {
+	writeJSON(w, status, map[string]any{"errorMessages": []string{message}})
+}
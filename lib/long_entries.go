@@ -0,0 +1,57 @@
+package timeentry
+
+import (
+	"time"
+
+	s "github.com/gyurkovicsferi/time-tracker/lib/store"
+	"github.com/ostafen/clover/v2/query"
+)
+
+// Why a time entry looks like it was left running, and when it probably
+// ended
+type LongEntry struct {
+	// Ran longer than the idle threshold after the end of the working hours,
+	// otherwise it is longer than the maximum entry length
+	Idle         bool
+	SuggestedEnd time.Time
+}
+
+// Checks the time entry from start to end against the maximum entry length
+// and the idle threshold of the settings. Returns nil if it looks fine.
+func CheckLength(settings *s.Settings, start, end time.Time) *LongEntry {
+	if idle := settings.IdleDuration(); idle > 0 {
+		_, workEnd, ok := settings.WorkingHours(start.Local())
+		if ok && workEnd.After(start) && end.Sub(workEnd) > idle {
+			return &LongEntry{Idle: true, SuggestedEnd: workEnd}
+		}
+	}
+
+	if maxLength := settings.MaxEntryDuration(); maxLength > 0 && end.Sub(start) > maxLength {
+		return &LongEntry{SuggestedEnd: start.Add(maxLength)}
+	}
+
+	return nil
+}
+
+// A finished time entry failing CheckLength
+type LongTimeEntry struct {
+	TimeEntry *s.TimeEntry
+	*LongEntry
+}
+
+// Returns the time entries started between from and to that look like they
+// were left running
+func LongEntries(store *s.Store, settings *s.Settings, from, to time.Time) []*LongTimeEntry {
+	timeEntries := store.GetTimeEntriesQuery(func(q *query.Query) *query.Query {
+		return q.Where(query.Field("start").GtEq(from).And(query.Field("start").LtEq(to))).
+			Sort(query.SortOption{Field: "start", Direction: 1})
+	})
+
+	long := make([]*LongTimeEntry, 0)
+	for _, timeEntry := range timeEntries {
+		if check := CheckLength(settings, timeEntry.Start, timeEntry.End); check != nil {
+			long = append(long, &LongTimeEntry{TimeEntry: timeEntry, LongEntry: check})
+		}
+	}
+	return long
+}
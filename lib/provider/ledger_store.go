@@ -0,0 +1,219 @@
+package provider
+
+import (
+	"log"
+
+	"github.com/google/uuid"
+	"github.com/ostafen/clover/v2"
+	"github.com/ostafen/clover/v2/document"
+	"github.com/ostafen/clover/v2/query"
+)
+
+const (
+	LedgerCollection         = "sync_ledger"
+	ProjectMappingCollection = "sync_project_mappings"
+)
+
+// Links a local time entry to its remote counterpart at a provider
+type LedgerEntry struct {
+	ID          string `clover:"id"`
+	Provider    string `clover:"provider"`
+	TimeEntryID string `clover:"time_entry_id"`
+	RemoteID    string `clover:"remote_id"`
+	// Content hash of the time entry as it was last pushed or pulled, empty
+	// if unknown. A different hash means the entry has to be updated remotely.
+	Hash string `clover:"hash"`
+	// Deleted locally, waiting to be deleted remotely
+	Deleted bool `clover:"deleted"`
+}
+
+type ProjectMapping struct {
+	ID              string `clover:"id"`
+	Provider        string `clover:"provider"`
+	Project         string `clover:"project"`
+	Task            string `clover:"task"`
+	RemoteProjectID string `clover:"remote_project_id"`
+	RemoteTaskID    string `clover:"remote_task_id"`
+}
+
+type LedgerStore struct {
+	db *clover.DB
+}
+
+func NewLedgerStore(db *clover.DB) *LedgerStore {
+	store := &LedgerStore{db: db}
+	store.createCollectionIfNotExists(LedgerCollection)
+	store.createCollectionIfNotExists(ProjectMappingCollection)
+	return store
+}
+
+func (s *LedgerStore) createCollectionIfNotExists(collection string) {
+	hasCollection, err := s.db.HasCollection(collection)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if !hasCollection {
+		err = s.db.CreateCollection(collection)
+		if err != nil {
+			log.Fatal(err)
+		}
+	}
+}
+
+func (s *LedgerStore) Insert(provider, timeEntryID, remoteID, hash string) error {
+	entry := &LedgerEntry{
+		ID:          uuid.New().String(),
+		Provider:    provider,
+		TimeEntryID: timeEntryID,
+		RemoteID:    remoteID,
+		Hash:        hash,
+	}
+
+	return s.db.Insert(LedgerCollection, document.NewDocumentOf(entry))
+}
+
+// Records the content hash of the time entry after it was pushed
+func (s *LedgerStore) SetHash(provider, timeEntryID, hash string) error {
+	return s.db.Update(query.NewQuery(LedgerCollection).
+		Where(query.Field("provider").Eq(provider).And(query.Field("time_entry_id").Eq(timeEntryID))),
+		map[string]interface{}{
+			"hash": hash,
+		},
+	)
+}
+
+// Returns nil if the time entry is not linked at the provider
+func (s *LedgerStore) GetByTimeEntryID(provider, timeEntryID string) (*LedgerEntry, error) {
+	return s.findFirst(query.Field("provider").Eq(provider).And(query.Field("time_entry_id").Eq(timeEntryID)))
+}
+
+// Returns nil if the remote entry is not linked to a local one
+func (s *LedgerStore) GetByRemoteID(provider, remoteID string) (*LedgerEntry, error) {
+	return s.findFirst(query.Field("provider").Eq(provider).And(query.Field("remote_id").Eq(remoteID)))
+}
+
+func (s *LedgerStore) findFirst(criteria query.Criteria) (*LedgerEntry, error) {
+	doc, err := s.db.FindFirst(query.NewQuery(LedgerCollection).Where(criteria))
+	if err != nil {
+		return nil, err
+	}
+
+	if doc == nil {
+		return nil, nil
+	}
+
+	entry := &LedgerEntry{}
+	if err := doc.Unmarshal(entry); err != nil {
+		return nil, err
+	}
+	return entry, nil
+}
+
+// Returns the links at the provider that are flagged for remote deletion
+func (s *LedgerStore) GetDeleted(provider string) ([]*LedgerEntry, error) {
+	docs, err := s.db.FindAll(query.NewQuery(LedgerCollection).
+		Where(query.Field("provider").Eq(provider).And(query.Field("deleted").IsTrue())),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	entries := make([]*LedgerEntry, len(docs))
+	for i, doc := range docs {
+		entries[i] = &LedgerEntry{}
+		if err := doc.Unmarshal(entries[i]); err != nil {
+			return nil, err
+		}
+	}
+	return entries, nil
+}
+
+// Flags the links of the time entry at every provider for remote deletion
+func (s *LedgerStore) MarkDeleted(timeEntryID string) error {
+	return s.db.Update(query.NewQuery(LedgerCollection).
+		Where(query.Field("time_entry_id").Eq(timeEntryID)),
+		map[string]interface{}{
+			"deleted": true,
+		},
+	)
+}
+
+// Clears the deletion flag of a restored time entry
+func (s *LedgerStore) UnmarkDeleted(timeEntryID string) error {
+	return s.db.Update(query.NewQuery(LedgerCollection).
+		Where(query.Field("time_entry_id").Eq(timeEntryID)),
+		map[string]interface{}{
+			"deleted": false,
+		},
+	)
+}
+
+// Removes the link once the entry is deleted remotely
+func (s *LedgerStore) Delete(provider, timeEntryID string) error {
+	return s.db.Delete(query.NewQuery(LedgerCollection).
+		Where(query.Field("provider").Eq(provider).And(query.Field("time_entry_id").Eq(timeEntryID))),
+	)
+}
+
+// Returns nil if the project / task has not been mapped at the provider yet
+func (s *LedgerStore) GetProjectMapping(provider, project, task string) (*ProjectMapping, error) {
+	doc, err := s.db.FindFirst(query.NewQuery(ProjectMappingCollection).Where(
+		query.Field("provider").Eq(provider).
+			And(query.Field("project").Eq(project)).
+			And(query.Field("task").Eq(task)),
+	))
+	if err != nil {
+		return nil, err
+	}
+
+	if doc == nil {
+		return nil, nil
+	}
+
+	mapping := &ProjectMapping{}
+	if err := doc.Unmarshal(mapping); err != nil {
+		return nil, err
+	}
+	return mapping, nil
+}
+
+func (s *LedgerStore) GetProjectMappings(provider string) ([]*ProjectMapping, error) {
+	docs, err := s.db.FindAll(query.NewQuery(ProjectMappingCollection).
+		Where(query.Field("provider").Eq(provider)).
+		Sort(query.SortOption{Field: "project", Direction: 1}, query.SortOption{Field: "task", Direction: 1}),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	mappings := make([]*ProjectMapping, len(docs))
+	for i, doc := range docs {
+		mappings[i] = &ProjectMapping{}
+		if err := doc.Unmarshal(mappings[i]); err != nil {
+			return nil, err
+		}
+	}
+	return mappings, nil
+}
+
+// Stores the mapping, replacing an existing one for the same project / task
+func (s *LedgerStore) SaveProjectMapping(mapping *ProjectMapping) error {
+	err := s.DeleteProjectMapping(mapping.Provider, mapping.Project, mapping.Task)
+	if err != nil {
+		return err
+	}
+
+	if mapping.ID == "" {
+		mapping.ID = uuid.New().String()
+	}
+	return s.db.Insert(ProjectMappingCollection, document.NewDocumentOf(mapping))
+}
+
+func (s *LedgerStore) DeleteProjectMapping(provider, project, task string) error {
+	return s.db.Delete(query.NewQuery(ProjectMappingCollection).Where(
+		query.Field("provider").Eq(provider).
+			And(query.Field("project").Eq(project)).
+			And(query.Field("task").Eq(task)),
+	))
+}
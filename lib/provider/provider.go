@@ -0,0 +1,97 @@
+// Package provider defines the interface time-tracking services implement to
+// be synchronized with the local time entries, and the shared bookkeeping of
+// which local entry corresponds to which remote one.
+package provider
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/gyurkovicsferi/time-tracker/lib/store"
+	"github.com/ostafen/clover/v2"
+)
+
+var ErrNotConfigured = errors.New("not configured")
+
+// Returned (wrapped) by Push for time entries the provider doesn't take, e.g.
+// ones without a Jira issue. They are reported as skipped, not as failures.
+var ErrSkipped = errors.New("skipped")
+
+// A time-tracking service the local time entries can be synchronized with
+type Provider interface {
+	Name() string
+	// Verifies the configuration and that the service is reachable
+	HealthCheck(ctx context.Context) error
+	// Creates the time entry remotely when remoteID is empty, updates it
+	// otherwise. Returns the remote ID of the entry.
+	Push(ctx context.Context, timeEntry *store.TimeEntry, remoteID string, mapping *ProjectMapping) (string, error)
+	Delete(ctx context.Context, remoteID string) error
+	// Returns the remote time entries started between start and end
+	Pull(ctx context.Context, start, end time.Time) ([]*RemoteTimeEntry, error)
+	// Resolves the remote project (and task) for a local project and task
+	MapProject(ctx context.Context, project, task string) (*ProjectMapping, error)
+}
+
+type RemoteTimeEntry struct {
+	RemoteID string
+	// Nil for entries that are still running
+	TimeEntry *store.TimeEntry
+}
+
+type Options struct {
+	// Returns the passphrase for unlocking encrypted credentials, nil when
+	// prompting is not possible
+	Passphrase func() (string, error)
+}
+
+// Creates a provider from its configuration stored in the database. Returns
+// an error wrapping ErrNotConfigured if it has not been configured yet.
+type Factory func(db *clover.DB, options Options) (Provider, error)
+
+var factories = make(map[string]Factory)
+
+// Makes a provider available by name, called from the init of the provider
+// packages
+func Register(name string, factory Factory) {
+	if _, exists := factories[name]; exists {
+		panic(fmt.Sprintf("provider %s is already registered", name))
+	}
+	factories[name] = factory
+}
+
+// Returns the names of the registered providers, sorted
+func Names() []string {
+	names := make([]string, 0, len(factories))
+	for name := range factories {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+func New(name string, db *clover.DB, options Options) (Provider, error) {
+	factory, ok := factories[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown provider %s, available: %v", name, Names())
+	}
+	return factory(db, options)
+}
+
+// Returns every registered provider that has been configured
+func Configured(db *clover.DB, options Options) ([]Provider, error) {
+	providers := make([]Provider, 0)
+	for _, name := range Names() {
+		p, err := New(name, db, options)
+		if errors.Is(err, ErrNotConfigured) {
+			continue
+		}
+		if err != nil {
+			return providers, err
+		}
+		providers = append(providers, p)
+	}
+	return providers, nil
+}
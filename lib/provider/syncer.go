@@ -0,0 +1,354 @@
+package provider
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/gyurkovicsferi/time-tracker/lib/importer"
+	"github.com/gyurkovicsferi/time-tracker/lib/store"
+	"github.com/ostafen/clover/v2/query"
+)
+
+type SyncAction string
+
+const (
+	SyncActionCreated  SyncAction = "created"
+	SyncActionUpdated  SyncAction = "updated"
+	SyncActionDeleted  SyncAction = "deleted"
+	SyncActionImported SyncAction = "imported"
+	SyncActionSkipped  SyncAction = "skipped"
+)
+
+type SyncResult struct {
+	Provider  string
+	TimeEntry *store.TimeEntry
+	RemoteID  string
+	Action    SyncAction
+	Err       error
+}
+
+type SyncSummary struct {
+	Succeeded map[SyncAction]int
+	Failed    int
+}
+
+func Summarize(results []*SyncResult) SyncSummary {
+	summary := SyncSummary{Succeeded: make(map[SyncAction]int)}
+	for _, result := range results {
+		if result.Err != nil {
+			summary.Failed++
+		} else {
+			summary.Succeeded[result.Action]++
+		}
+	}
+	return summary
+}
+
+// Synchronizes the local time entries with a provider
+type Syncer struct {
+	store    *store.Store
+	ledger   *LedgerStore
+	provider Provider
+}
+
+func NewSyncer(store *store.Store, ledger *LedgerStore, provider Provider) *Syncer {
+	return &Syncer{store: store, ledger: ledger, provider: provider}
+}
+
+func (s *Syncer) Provider() Provider {
+	return s.provider
+}
+
+// Uploads the local time entries started between start and end: new entries
+// are created and the ones changed since the last push are updated. Entries
+// deleted locally are deleted remotely first, whenever they started. A failed
+// upload doesn't stop the run: it is reported in its result and, as the
+// ledger still shows the old state, retried on the next run.
+func (s *Syncer) Push(ctx context.Context, start, end time.Time) ([]*SyncResult, error) {
+	results, err := s.pushDeletions(ctx)
+	if err != nil {
+		return results, err
+	}
+
+	timeEntries := s.store.GetTimeEntriesQuery(func(q *query.Query) *query.Query {
+		return q.Where(query.Field("start").GtEq(start).And(query.Field("start").LtEq(end)))
+	})
+
+	for _, timeEntry := range timeEntries {
+		link, err := s.ledger.GetByTimeEntryID(s.provider.Name(), timeEntry.ID)
+		if err != nil {
+			return results, err
+		}
+
+		result := s.newResult(timeEntry)
+		results = append(results, result)
+
+		switch {
+		case link == nil:
+			result.Action = SyncActionCreated
+			err = s.create(ctx, result)
+		case link.Hash == importer.ContentHash(timeEntry):
+			result.RemoteID = link.RemoteID
+			result.Action = SyncActionSkipped
+			continue
+		default:
+			result.RemoteID = link.RemoteID
+			result.Action = SyncActionUpdated
+			err = s.update(ctx, result, link)
+		}
+		if err != nil {
+			return results, err
+		}
+		if result.Err != nil && ctx.Err() != nil {
+			return results, ctx.Err()
+		}
+	}
+
+	return results, nil
+}
+
+// Deletes the remote entries of the links flagged as deleted. A flag left on
+// a time entry that has been restored since is cleared instead.
+func (s *Syncer) pushDeletions(ctx context.Context) ([]*SyncResult, error) {
+	links, err := s.ledger.GetDeleted(s.provider.Name())
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]*SyncResult, 0, len(links))
+	for _, link := range links {
+		if s.store.GetTimeEntry(link.TimeEntryID) != nil {
+			if err := s.ledger.UnmarkDeleted(link.TimeEntryID); err != nil {
+				return results, err
+			}
+			continue
+		}
+
+		result := s.newResult(&store.TimeEntry{ID: link.TimeEntryID})
+		result.RemoteID = link.RemoteID
+		result.Action = SyncActionDeleted
+		results = append(results, result)
+
+		if err := s.delete(ctx, result); err != nil {
+			return results, err
+		}
+		if result.Err != nil && ctx.Err() != nil {
+			return results, ctx.Err()
+		}
+	}
+
+	return results, nil
+}
+
+// Imports the remote time entries started between start and end. Entries
+// that are already linked to a local entry are skipped.
+func (s *Syncer) Pull(ctx context.Context, start, end time.Time) ([]*SyncResult, error) {
+	remoteEntries, err := s.provider.Pull(ctx, start, end)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]*SyncResult, 0, len(remoteEntries))
+	for _, remoteEntry := range remoteEntries {
+		result := s.newResult(nil)
+		result.RemoteID = remoteEntry.RemoteID
+		result.Action = SyncActionSkipped
+		results = append(results, result)
+
+		linked, err := s.ledger.GetByRemoteID(s.provider.Name(), remoteEntry.RemoteID)
+		if err != nil {
+			return results, err
+		}
+
+		// Running entries are imported once they are stopped
+		if linked != nil || remoteEntry.TimeEntry == nil {
+			continue
+		}
+
+		s.store.InsertTimeEntry(remoteEntry.TimeEntry)
+		if err := s.ledger.Insert(s.provider.Name(), remoteEntry.TimeEntry.ID, remoteEntry.RemoteID, importer.ContentHash(remoteEntry.TimeEntry)); err != nil {
+			return results, err
+		}
+
+		result.TimeEntry = remoteEntry.TimeEntry
+		result.Action = SyncActionImported
+	}
+
+	return results, nil
+}
+
+// Drains the outbox of queued time entry changes to every syncer. An item is
+// removed once all of them succeeded, failed items stay queued and are
+// retried on the next flush. Retrying is safe as the ledger records what has
+// already been applied.
+func Flush(ctx context.Context, timeEntryStore *store.Store, syncers ...*Syncer) ([]*SyncResult, error) {
+	items := timeEntryStore.GetOutbox()
+
+	results := make([]*SyncResult, 0, len(items)*len(syncers))
+	for _, item := range items {
+		failures := make([]error, 0)
+
+		for _, syncer := range syncers {
+			if err := ctx.Err(); err != nil {
+				return results, err
+			}
+
+			result, err := syncer.flushItem(ctx, item)
+			if err != nil {
+				return results, err
+			}
+			results = append(results, result)
+
+			if result.Err != nil {
+				failures = append(failures, result.Err)
+			}
+		}
+
+		if len(failures) > 0 {
+			timeEntryStore.MarkOutboxItemFailed(item, errors.Join(failures...))
+			continue
+		}
+
+		timeEntryStore.RemoveOutboxItem(item.ID)
+	}
+
+	return results, nil
+}
+
+func (s *Syncer) flushItem(ctx context.Context, item *store.OutboxItem) (*SyncResult, error) {
+	link, err := s.ledger.GetByTimeEntryID(s.provider.Name(), item.TimeEntryID)
+	if err != nil {
+		return nil, err
+	}
+
+	result := s.newResult(&store.TimeEntry{ID: item.TimeEntryID})
+	result.Action = SyncActionSkipped
+	if link != nil {
+		result.RemoteID = link.RemoteID
+	}
+
+	if item.Operation == store.OutboxDelete {
+		// Never uploaded, nothing to delete
+		if link == nil {
+			return result, nil
+		}
+
+		result.Action = SyncActionDeleted
+		return result, s.delete(ctx, result)
+	}
+
+	timeEntry := s.store.GetTimeEntry(item.TimeEntryID)
+	if timeEntry == nil {
+		return result, nil
+	}
+	result.TimeEntry = timeEntry
+
+	if link == nil {
+		result.Action = SyncActionCreated
+		return result, s.create(ctx, result)
+	}
+
+	result.Action = SyncActionUpdated
+	return result, s.update(ctx, result, link)
+}
+
+// Turns ErrSkipped into the skipped action
+func (s *Syncer) skipped(result *SyncResult) bool {
+	if !errors.Is(result.Err, ErrSkipped) {
+		return false
+	}
+
+	result.Action = SyncActionSkipped
+	result.Err = nil
+	return true
+}
+
+// Creates the entry of the result remotely and links it. Remote failures are
+// recorded in the result, only local failures are returned.
+func (s *Syncer) create(ctx context.Context, result *SyncResult) error {
+	mapping, err := s.mapping(ctx, result.TimeEntry)
+	if err != nil {
+		result.Err = err
+		return nil
+	}
+
+	result.RemoteID, result.Err = s.provider.Push(ctx, result.TimeEntry, "", mapping)
+	if s.skipped(result) || result.Err != nil {
+		return nil
+	}
+
+	return s.ledger.Insert(s.provider.Name(), result.TimeEntry.ID, result.RemoteID, importer.ContentHash(result.TimeEntry))
+}
+
+// Updates the linked remote entry of the result and records the pushed
+// content. Remote failures are recorded in the result, only local failures
+// are returned.
+func (s *Syncer) update(ctx context.Context, result *SyncResult, link *LedgerEntry) error {
+	timeEntry := result.TimeEntry
+	mapping, err := s.mapping(ctx, timeEntry)
+	if err != nil {
+		result.Err = err
+		return nil
+	}
+
+	remoteID, err := s.provider.Push(ctx, timeEntry, link.RemoteID, mapping)
+	result.Err = err
+	if s.skipped(result) {
+		// No longer taken by the provider, e.g. the issue key was removed
+		return s.ledger.Delete(s.provider.Name(), timeEntry.ID)
+	}
+	if err != nil {
+		return nil
+	}
+
+	hash := importer.ContentHash(timeEntry)
+	if remoteID == link.RemoteID {
+		return s.ledger.SetHash(s.provider.Name(), timeEntry.ID, hash)
+	}
+
+	// The provider recreated the entry under a new remote ID
+	result.RemoteID = remoteID
+	if err := s.ledger.Delete(s.provider.Name(), timeEntry.ID); err != nil {
+		return err
+	}
+	return s.ledger.Insert(s.provider.Name(), timeEntry.ID, remoteID, hash)
+}
+
+// Deletes the remote entry of the result and unlinks it. Remote failures are
+// recorded in the result, only local failures are returned.
+func (s *Syncer) delete(ctx context.Context, result *SyncResult) error {
+	result.Err = s.provider.Delete(ctx, result.RemoteID)
+	if result.Err != nil {
+		return nil
+	}
+
+	return s.ledger.Delete(s.provider.Name(), result.TimeEntry.ID)
+}
+
+// Returns the stored project mapping of the entry, asking the provider to
+// resolve it otherwise. Only a mapping that matched something is stored, so
+// an unmatched project is looked up again once it exists remotely.
+func (s *Syncer) mapping(ctx context.Context, timeEntry *store.TimeEntry) (*ProjectMapping, error) {
+	mapping, err := s.ledger.GetProjectMapping(s.provider.Name(), timeEntry.Project, timeEntry.Task)
+	if err != nil || mapping != nil {
+		return mapping, err
+	}
+
+	mapping, err = s.provider.MapProject(ctx, timeEntry.Project, timeEntry.Task)
+	if err != nil {
+		return nil, err
+	}
+
+	mapping.Provider = s.provider.Name()
+	mapping.Project = timeEntry.Project
+	mapping.Task = timeEntry.Task
+	if mapping.RemoteProjectID == "" && mapping.RemoteTaskID == "" {
+		return mapping, nil
+	}
+	return mapping, s.ledger.SaveProjectMapping(mapping)
+}
+
+func (s *Syncer) newResult(timeEntry *store.TimeEntry) *SyncResult {
+	return &SyncResult{Provider: s.provider.Name(), TimeEntry: timeEntry}
+}
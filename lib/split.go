@@ -0,0 +1,109 @@
+package timeentry
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	s "github.com/gyurkovicsferi/time-tracker/lib/store"
+)
+
+// Splits the time entry at the time into two. The first part keeps the id,
+// the second part gets the task if it's not empty. Returns the two parts.
+func Split(store *s.Store, timeEntry *s.TimeEntry, at time.Time, task string) (*s.TimeEntry, *s.TimeEntry, error) {
+	if !at.After(timeEntry.Start) || !at.Before(timeEntry.End) {
+		return nil, nil, fmt.Errorf("%s is not between the start (%s) and the end (%s) of the time entry",
+			at.Local().Format(time.DateTime), timeEntry.Start.Local().Format(time.DateTime), timeEntry.End.Local().Format(time.DateTime))
+	}
+
+	first := *timeEntry
+	first.End = at
+
+	second := *timeEntry
+	second.ID = uuid.New().String()
+	second.Start = at
+	second.Tags = slices.Clone(timeEntry.Tags)
+	if task != "" {
+		second.Task = task
+	}
+
+	err := Apply(store, &Changes{
+		Update: []*s.TimeEntry{&first},
+		Create: []*s.TimeEntry{&second},
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	return &first, &second, nil
+}
+
+// The longest gap between two time entries that are still merged, e.g. of
+// times entered by hand
+const MaxMergeGap = time.Minute
+
+// Combines two adjacent time entries of the same project and task into the
+// earlier one, the later one is deleted. Notes and tags of both are kept.
+func Merge(store *s.Store, a, b *s.TimeEntry) (*s.TimeEntry, error) {
+	if a.ID == b.ID {
+		return nil, fmt.Errorf("can't merge a time entry with itself")
+	}
+	if a.Project != b.Project || a.Task != b.Task {
+		return nil, fmt.Errorf("can't merge time entries of different tasks (%s / %s and %s / %s)", a.Project, a.Task, b.Project, b.Task)
+	}
+
+	first, second := a, b
+	if second.Start.Before(first.Start) {
+		first, second = second, first
+	}
+	if gap := second.Start.Sub(first.End); gap > MaxMergeGap {
+		return nil, fmt.Errorf("the time entries are not adjacent, there are %s between them", gap.Round(time.Second))
+	}
+
+	merged := *first
+	merged.End = second.End
+	if first.End.After(second.End) {
+		merged.End = first.End
+	}
+
+	// Nothing else can be tracked between them
+	between := slices.DeleteFunc(Overlapping(store, &merged), func(other *s.TimeEntry) bool {
+		return other.ID == second.ID
+	})
+	if len(between) > 0 {
+		other := between[0]
+		return nil, fmt.Errorf("the time entries are not adjacent, %s / %s (%s - %s, id %s) is between them",
+			other.Project, other.Task,
+			other.Start.Local().Format(time.DateTime), other.End.Local().Format(time.DateTime),
+			other.ID)
+	}
+
+	merged.Note = mergeNotes(first.Note, second.Note)
+	merged.Tags = slices.Clone(first.Tags)
+	for _, tag := range second.Tags {
+		if !slices.Contains(merged.Tags, tag) {
+			merged.Tags = append(merged.Tags, tag)
+		}
+	}
+
+	err := Apply(store, &Changes{
+		Update: []*s.TimeEntry{&merged},
+		Delete: []string{second.ID},
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &merged, nil
+}
+
+func mergeNotes(a, b string) string {
+	a, b = strings.TrimSpace(a), strings.TrimSpace(b)
+	if a == "" || a == b {
+		return b
+	}
+	if b == "" {
+		return a
+	}
+	return a + "; " + b
+}
@@ -18,19 +18,29 @@ type CurrentTimeEntry struct {
 	ID      string    `clover:"id"`
 	Project string    `clover:"project"`
 	Task    string    `clover:"task"`
+	Note    string    `clover:"note"`
+	Tags    []string  `clover:"tags"`
 	Start   time.Time `clover:"start"`
+	// The time entry that was running, and got stopped, when this one started
+	Stopped string `clover:"stopped"`
 }
 
 type TimeEntry struct {
 	ID      string    `clover:"id"`
 	Project string    `clover:"project"`
 	Task    string    `clover:"task"`
+	Note    string    `clover:"note"`
+	Tags    []string  `clover:"tags"`
 	Start   time.Time `clover:"start"`
 	End     time.Time `clover:"end"`
 }
 
 type Store struct {
 	db *clover.DB
+	// Set on the stores returned by ForUndo
+	undoOf string
+	// History group of the stores returned by Batch
+	group string
 }
 
 func NewStore(db *clover.DB) *Store {
@@ -50,6 +60,14 @@ func (s *Store) InsertCurrentTimeEntry(currentTimeEntry *CurrentTimeEntry) strin
 	return id
 }
 
+func (s *Store) UpdateCurrentTimeEntry(currentTimeEntry *CurrentTimeEntry) {
+	doc := document.NewDocumentOf(currentTimeEntry)
+	err := s.db.Update(query.NewQuery(CurrentTimeEntryCollection).Where(query.Field("id").Eq(currentTimeEntry.ID)), doc.AsMap())
+	if err != nil {
+		log.Fatal(err)
+	}
+}
+
 func (s *Store) DeleteCurrentTimeEntry() {
 	err := s.db.Delete(query.NewQuery(CurrentTimeEntryCollection))
 	if err != nil {
@@ -65,9 +83,29 @@ func (s *Store) InsertTimeEntry(timeEntry *TimeEntry) string {
 		log.Fatal(err)
 	}
 
+	s.recordHistory([2]*TimeEntry{nil, timeEntry})
 	return id
 }
 
+// Inserts all the time entries or none of them
+func (s *Store) InsertTimeEntries(timeEntries []*TimeEntry) {
+	docs := make([]*document.Document, len(timeEntries))
+	for i, timeEntry := range timeEntries {
+		docs[i] = document.NewDocumentOf(timeEntry)
+	}
+
+	err := s.db.Insert(TimeEntryCollection, docs...)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	changes := make([][2]*TimeEntry, len(timeEntries))
+	for i, timeEntry := range timeEntries {
+		changes[i] = [2]*TimeEntry{nil, timeEntry}
+	}
+	s.recordHistory(changes...)
+}
+
 func (s *Store) GetCurrentTimeEntry() *CurrentTimeEntry {
 	doc, err := s.db.FindFirst(query.NewQuery(CurrentTimeEntryCollection))
 	if err != nil {
@@ -102,6 +140,20 @@ func (s *Store) GetTimeEntriesQuery(queryDecorator func(*query.Query) *query.Que
 	return timeEntries
 }
 
+// Returns nil if there is no time entry with the id
+func (s *Store) GetTimeEntry(id string) *TimeEntry {
+	doc, err := s.db.FindFirst(query.NewQuery(TimeEntryCollection).Where(query.Field("id").Eq(id)))
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if doc == nil {
+		return nil
+	}
+
+	return unmarshalTimeEntry(doc)
+}
+
 func unmarshalTimeEntry(doc *document.Document) *TimeEntry {
 	timeEntry := &TimeEntry{}
 	err := doc.Unmarshal(timeEntry)
@@ -149,6 +201,15 @@ func EndOfDay(t time.Time) time.Time {
 	return time.Date(t.Year(), t.Month(), t.Day(), 23, 59, 59, 999, t.Location())
 }
 
+// Returns the start of the Monday of the week of t
+func StartOfWeek(t time.Time) time.Time {
+	weekday := int(t.Weekday())
+	if weekday == 0 {
+		weekday = 7
+	}
+	return StartOfDay(t.AddDate(0, 0, -weekday+1))
+}
+
 func StartOfMinute(t time.Time) time.Time {
 	return time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), 0, 0, t.Location())
 }
@@ -184,16 +245,41 @@ func (store *Store) GetTasks(project string) []string {
 }
 
 func (s *Store) UpdateTimeEntry(timeEntry *TimeEntry) {
+	before := s.GetTimeEntry(timeEntry.ID)
+
 	doc := document.NewDocumentOf(timeEntry)
 	err := s.db.Update(query.NewQuery(TimeEntryCollection).Where(query.Field("id").Eq(timeEntry.ID)), doc.AsMap())
 	if err != nil {
 		log.Fatal(err)
 	}
+
+	if before != nil {
+		s.recordHistory([2]*TimeEntry{before, timeEntry})
+	}
 }
 
 func (s *Store) DeleteTimeEntry(id string) {
-	err := s.db.Delete(query.NewQuery(TimeEntryCollection).Where(query.Field("id").Eq(id)))
+	s.DeleteTimeEntries([]string{id})
+}
+
+// Deletes the time entries, recorded as one operation in the history
+func (s *Store) DeleteTimeEntries(ids []string) {
+	values := make([]interface{}, len(ids))
+	for i, id := range ids {
+		values[i] = id
+	}
+	q := query.NewQuery(TimeEntryCollection).Where(query.Field("id").In(values...))
+
+	before := s.GetTimeEntriesQuery(func(*query.Query) *query.Query { return q })
+
+	err := s.db.Delete(q)
 	if err != nil {
 		log.Fatal(err)
 	}
+
+	changes := make([][2]*TimeEntry, len(before))
+	for i, timeEntry := range before {
+		changes[i] = [2]*TimeEntry{timeEntry, nil}
+	}
+	s.recordHistory(changes...)
 }
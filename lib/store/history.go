@@ -0,0 +1,192 @@
+package store
+
+import (
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/gyurkovicsferi/time-tracker/lib/db"
+	"github.com/ostafen/clover/v2/document"
+	"github.com/ostafen/clover/v2/query"
+)
+
+const HistoryCollection = "history"
+
+type HistoryOperation string
+
+const (
+	HistoryCreate HistoryOperation = "create"
+	HistoryUpdate HistoryOperation = "update"
+	HistoryDelete HistoryOperation = "delete"
+)
+
+// Recorded as the command of the changes, the CLI arguments by default
+var HistoryCommand = strings.Join(os.Args[1:], " ")
+
+// A change of a time entry. The history is append-only, entries are only
+// flagged when they are undone.
+type HistoryEntry struct {
+	ID string `clover:"id"`
+	// The changes of one operation (e.g. an import) share the group
+	Group       string           `clover:"group"`
+	TimeEntryID string           `clover:"time_entry_id"`
+	Operation   HistoryOperation `clover:"operation"`
+	// Nil for creates
+	Before *TimeEntry `clover:"before"`
+	// Nil for deletes
+	After   *TimeEntry `clover:"after"`
+	At      time.Time  `clover:"at"`
+	Command string     `clover:"command"`
+	Undone  bool       `clover:"undone"`
+	// The group this change reverted, set for the changes made by undo
+	UndoOf string `clover:"undo_of"`
+}
+
+func (s *Store) createHistoryCollectionIfNotExists() {
+	hasCollection, err := s.db.HasCollection(HistoryCollection)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if !hasCollection {
+		err = s.db.CreateCollection(HistoryCollection)
+		if err != nil {
+			log.Fatal(err)
+		}
+	}
+}
+
+// Returns a store that records its changes as the undo of the group
+func (s *Store) ForUndo(group string) *Store {
+	undo := *s
+	undo.undoOf = group
+	return &undo
+}
+
+// Returns a store that records all its changes as one operation, so they are
+// undone together
+func (s *Store) Batch() *Store {
+	batch := *s
+	batch.group = uuid.New().String()
+	return &batch
+}
+
+// Runs fn in a single database transaction, see db.Transaction
+func (s *Store) Transaction(fn func() error) error {
+	return db.Transaction(s.db, fn)
+}
+
+// Records the changes of one operation. Changes without a before snapshot
+// are creates, without an after snapshot deletes.
+func (s *Store) recordHistory(changes ...[2]*TimeEntry) {
+	if len(changes) == 0 {
+		return
+	}
+
+	group := s.group
+	if group == "" {
+		group = uuid.New().String()
+	}
+	now := time.Now()
+
+	docs := make([]*document.Document, len(changes))
+	for i, change := range changes {
+		before, after := change[0], change[1]
+
+		operation := HistoryUpdate
+		if before == nil {
+			operation = HistoryCreate
+		} else if after == nil {
+			operation = HistoryDelete
+		}
+
+		entry := &HistoryEntry{
+			ID:        uuid.New().String(),
+			Group:     group,
+			Operation: operation,
+			Before:    before,
+			After:     after,
+			At:        now,
+			Command:   HistoryCommand,
+			UndoOf:    s.undoOf,
+		}
+		if before != nil {
+			entry.TimeEntryID = before.ID
+		} else {
+			entry.TimeEntryID = after.ID
+		}
+		docs[i] = document.NewDocumentOf(entry)
+	}
+
+	err := s.db.Insert(HistoryCollection, docs...)
+	if err != nil {
+		log.Fatal(err)
+	}
+}
+
+// Returns the changes of the time entry, oldest first
+func (s *Store) GetHistory(timeEntryID string) []*HistoryEntry {
+	return s.findHistory(query.NewQuery(HistoryCollection).
+		Where(query.Field("time_entry_id").Eq(timeEntryID)).
+		Sort(query.SortOption{Field: "at", Direction: 1}))
+}
+
+// Returns the last n operations that can be undone, latest first. The
+// changes made by undo can't be undone themselves.
+func (s *Store) GetUndoableOperations(n int) [][]*HistoryEntry {
+	return groupOperations(s.findHistory(query.NewQuery(HistoryCollection).
+		Where(query.Field("undone").Eq(false).And(query.Field("undo_of").Eq(""))).
+		Sort(query.SortOption{Field: "at", Direction: -1})), n)
+}
+
+// Returns the last n operations, latest first
+func (s *Store) GetRecentHistory(n int) [][]*HistoryEntry {
+	return groupOperations(s.findHistory(query.NewQuery(HistoryCollection).
+		Sort(query.SortOption{Field: "at", Direction: -1})), n)
+}
+
+// Groups the changes into at most n operations, keeping their order
+func groupOperations(entries []*HistoryEntry, n int) [][]*HistoryEntry {
+	operations := make([][]*HistoryEntry, 0, n)
+	groups := make(map[string]int)
+	for _, entry := range entries {
+		index, ok := groups[entry.Group]
+		if !ok {
+			if len(operations) == n {
+				break
+			}
+			index = len(operations)
+			groups[entry.Group] = index
+			operations = append(operations, nil)
+		}
+		operations[index] = append(operations[index], entry)
+	}
+	return operations
+}
+
+func (s *Store) MarkUndone(group string) {
+	err := s.db.Update(query.NewQuery(HistoryCollection).Where(query.Field("group").Eq(group)), map[string]interface{}{
+		"undone": true,
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+}
+
+func (s *Store) findHistory(q *query.Query) []*HistoryEntry {
+	docs, err := s.db.FindAll(q)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	entries := make([]*HistoryEntry, len(docs))
+	for i, doc := range docs {
+		entries[i] = &HistoryEntry{}
+		if err := doc.Unmarshal(entries[i]); err != nil {
+			log.Fatal(err)
+		}
+	}
+	return entries
+}
@@ -7,6 +7,10 @@ import (
 func (s *Store) Migrate() {
 	s.createTimeEntryCollectionIfNotExists()
 	s.createCurrentTimeEntryCollectionIfNotExists()
+	s.createOutboxCollectionsIfNotExists()
+	s.createHistoryCollectionIfNotExists()
+	s.createTrashCollectionIfNotExists()
+	s.createSettingsCollectionIfNotExists()
 }
 
 func (s *Store) createTimeEntryCollectionIfNotExists() {
@@ -0,0 +1,147 @@
+package store
+
+import (
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/ostafen/clover/v2/document"
+	"github.com/ostafen/clover/v2/query"
+)
+
+const (
+	OutboxCollection         = "outbox"
+	OutboxSettingsCollection = "outbox-settings"
+)
+
+type OutboxOperation string
+
+const (
+	OutboxUpsert OutboxOperation = "upsert"
+	OutboxDelete OutboxOperation = "delete"
+)
+
+// A time entry change waiting to be synchronized
+type OutboxItem struct {
+	ID          string          `clover:"id"`
+	TimeEntryID string          `clover:"time_entry_id"`
+	Operation   OutboxOperation `clover:"operation"`
+	EnqueuedAt  time.Time       `clover:"enqueued_at"`
+	Attempts    int             `clover:"attempts"`
+	LastError   string          `clover:"last_error"`
+}
+
+type OutboxSettings struct {
+	Enabled bool `clover:"enabled"`
+}
+
+func (s *Store) createOutboxCollectionsIfNotExists() {
+	for _, collection := range []string{OutboxCollection, OutboxSettingsCollection} {
+		hasCollection, err := s.db.HasCollection(collection)
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		if !hasCollection {
+			err = s.db.CreateCollection(collection)
+			if err != nil {
+				log.Fatal(err)
+			}
+		}
+	}
+}
+
+func (s *Store) IsOutboxEnabled() bool {
+	doc, err := s.db.FindFirst(query.NewQuery(OutboxSettingsCollection))
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if doc == nil {
+		return false
+	}
+
+	settings := &OutboxSettings{}
+	err = doc.Unmarshal(settings)
+	if err != nil {
+		log.Fatal(err)
+	}
+	return settings.Enabled
+}
+
+func (s *Store) SetOutboxEnabled(enabled bool) {
+	err := s.db.Delete(query.NewQuery(OutboxSettingsCollection))
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	err = s.db.Insert(OutboxSettingsCollection, document.NewDocumentOf(&OutboxSettings{Enabled: enabled}))
+	if err != nil {
+		log.Fatal(err)
+	}
+}
+
+// Queues a change of the time entry if the outbox is enabled. A pending
+// change of the same entry is replaced, so a deletion supersedes an update.
+func (s *Store) EnqueueOutbox(timeEntryID string, operation OutboxOperation) {
+	if !s.IsOutboxEnabled() {
+		return
+	}
+
+	err := s.db.Delete(query.NewQuery(OutboxCollection).Where(query.Field("time_entry_id").Eq(timeEntryID)))
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	item := &OutboxItem{
+		ID:          uuid.New().String(),
+		TimeEntryID: timeEntryID,
+		Operation:   operation,
+		EnqueuedAt:  time.Now(),
+	}
+
+	err = s.db.Insert(OutboxCollection, document.NewDocumentOf(item))
+	if err != nil {
+		log.Fatal(err)
+	}
+}
+
+// Returns the pending changes, oldest first
+func (s *Store) GetOutbox() []*OutboxItem {
+	docs, err := s.db.FindAll(query.NewQuery(OutboxCollection).Sort(query.SortOption{
+		Field:     "enqueued_at",
+		Direction: 1,
+	}))
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	items := make([]*OutboxItem, len(docs))
+	for i, doc := range docs {
+		items[i] = &OutboxItem{}
+		err = doc.Unmarshal(items[i])
+		if err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	return items
+}
+
+func (s *Store) RemoveOutboxItem(id string) {
+	err := s.db.Delete(query.NewQuery(OutboxCollection).Where(query.Field("id").Eq(id)))
+	if err != nil {
+		log.Fatal(err)
+	}
+}
+
+// Keeps the item queued, recording the failure
+func (s *Store) MarkOutboxItemFailed(item *OutboxItem, failure error) {
+	err := s.db.Update(query.NewQuery(OutboxCollection).Where(query.Field("id").Eq(item.ID)), map[string]interface{}{
+		"attempts":   item.Attempts + 1,
+		"last_error": failure.Error(),
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+}
@@ -0,0 +1,157 @@
+package store
+
+import (
+	"fmt"
+	"log"
+	"slices"
+	"time"
+
+	"github.com/ostafen/clover/v2/document"
+	"github.com/ostafen/clover/v2/query"
+)
+
+const SettingsCollection = "settings"
+
+// Layout of the start and end of the working hours
+const WorkTimeLayout = "15:04"
+
+type Settings struct {
+	// Start and end of the working hours, e.g. 09:00 and 17:00
+	WorkStart string `clover:"work_start"`
+	WorkEnd   string `clover:"work_end"`
+	// Working days of the week
+	WorkDays []time.Weekday `clover:"work_days"`
+	// Time entries longer than this are suspicious, e.g. 10h. 0 disables the
+	// check.
+	MaxEntryLength string `clover:"max_entry_length"`
+	// Time entries running longer than this after the end of the working
+	// hours were probably left running, e.g. 2h. 0 disables the check.
+	IdleThreshold string `clover:"idle_threshold"`
+}
+
+func DefaultSettings() *Settings {
+	return &Settings{
+		WorkStart:      "09:00",
+		WorkEnd:        "17:00",
+		WorkDays:       []time.Weekday{time.Monday, time.Tuesday, time.Wednesday, time.Thursday, time.Friday},
+		MaxEntryLength: "10h",
+		IdleThreshold:  "2h",
+	}
+}
+
+// Returns the working hours on the day of t, ok is false on days off
+func (settings *Settings) WorkingHours(t time.Time) (start, end time.Time, ok bool) {
+	if !slices.Contains(settings.WorkDays, t.Weekday()) {
+		return time.Time{}, time.Time{}, false
+	}
+
+	startTime, err := time.Parse(WorkTimeLayout, settings.WorkStart)
+	if err != nil {
+		return time.Time{}, time.Time{}, false
+	}
+	endTime, err := time.Parse(WorkTimeLayout, settings.WorkEnd)
+	if err != nil {
+		return time.Time{}, time.Time{}, false
+	}
+
+	start = time.Date(t.Year(), t.Month(), t.Day(), startTime.Hour(), startTime.Minute(), 0, 0, t.Location())
+	end = time.Date(t.Year(), t.Month(), t.Day(), endTime.Hour(), endTime.Minute(), 0, 0, t.Location())
+	return start, end, end.After(start)
+}
+
+// Returns 0 if the check of the long time entries is disabled
+func (settings *Settings) MaxEntryDuration() time.Duration {
+	duration, _ := time.ParseDuration(settings.MaxEntryLength)
+	return duration
+}
+
+// Returns 0 if the check of the idle time entries is disabled
+func (settings *Settings) IdleDuration() time.Duration {
+	duration, _ := time.ParseDuration(settings.IdleThreshold)
+	return duration
+}
+
+func (settings *Settings) Validate() error {
+	start, err := time.Parse(WorkTimeLayout, settings.WorkStart)
+	if err != nil {
+		return fmt.Errorf("invalid start of the working hours %q, expected e.g. 09:00", settings.WorkStart)
+	}
+	end, err := time.Parse(WorkTimeLayout, settings.WorkEnd)
+	if err != nil {
+		return fmt.Errorf("invalid end of the working hours %q, expected e.g. 17:00", settings.WorkEnd)
+	}
+	if !end.After(start) {
+		return fmt.Errorf("the working hours end (%s) before they start (%s)", settings.WorkEnd, settings.WorkStart)
+	}
+	if duration, err := time.ParseDuration(settings.MaxEntryLength); err != nil || duration < 0 {
+		return fmt.Errorf("invalid maximum entry length %q, expected e.g. 10h or 0 to disable it", settings.MaxEntryLength)
+	}
+	if duration, err := time.ParseDuration(settings.IdleThreshold); err != nil || duration < 0 {
+		return fmt.Errorf("invalid idle threshold %q, expected e.g. 2h or 0 to disable it", settings.IdleThreshold)
+	}
+	return nil
+}
+
+func (s *Store) createSettingsCollectionIfNotExists() {
+	hasCollection, err := s.db.HasCollection(SettingsCollection)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if !hasCollection {
+		err = s.db.CreateCollection(SettingsCollection)
+		if err != nil {
+			log.Fatal(err)
+		}
+	}
+}
+
+// Returns the saved settings, the ones that were never set have their
+// default value
+func (s *Store) GetSettings() *Settings {
+	defaults := DefaultSettings()
+
+	doc, err := s.db.FindFirst(query.NewQuery(SettingsCollection))
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if doc == nil {
+		return defaults
+	}
+
+	settings := &Settings{}
+	err = doc.Unmarshal(settings)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if settings.WorkStart == "" {
+		settings.WorkStart = defaults.WorkStart
+	}
+	if settings.WorkEnd == "" {
+		settings.WorkEnd = defaults.WorkEnd
+	}
+	if len(settings.WorkDays) == 0 {
+		settings.WorkDays = defaults.WorkDays
+	}
+	if settings.MaxEntryLength == "" {
+		settings.MaxEntryLength = defaults.MaxEntryLength
+	}
+	if settings.IdleThreshold == "" {
+		settings.IdleThreshold = defaults.IdleThreshold
+	}
+	return settings
+}
+
+func (s *Store) SaveSettings(settings *Settings) {
+	err := s.db.Delete(query.NewQuery(SettingsCollection))
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	err = s.db.Insert(SettingsCollection, document.NewDocumentOf(settings))
+	if err != nil {
+		log.Fatal(err)
+	}
+}
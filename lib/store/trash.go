@@ -0,0 +1,137 @@
+package store
+
+import (
+	"log"
+	"slices"
+	"time"
+
+	"github.com/ostafen/clover/v2/document"
+	"github.com/ostafen/clover/v2/query"
+)
+
+const TrashCollection = "trash"
+
+// A deleted time entry, kept until it is purged
+type TrashEntry struct {
+	// The ID of the time entry
+	ID        string     `clover:"id"`
+	TimeEntry *TimeEntry `clover:"time_entry"`
+	DeletedAt time.Time  `clover:"deleted_at"`
+}
+
+func (s *Store) createTrashCollectionIfNotExists() {
+	hasCollection, err := s.db.HasCollection(TrashCollection)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if !hasCollection {
+		err = s.db.CreateCollection(TrashCollection)
+		if err != nil {
+			log.Fatal(err)
+		}
+	}
+}
+
+// Moves the time entry to the trash. Returns false if there is no time entry
+// with the id.
+func (s *Store) TrashTimeEntry(id string) bool {
+	return s.TrashTimeEntries([]string{id}) == 1
+}
+
+// Moves the time entries to the trash at once. Returns the number of trashed
+// entries, ids without a time entry and repeated ids are ignored.
+func (s *Store) TrashTimeEntries(ids []string) int {
+	ids = slices.Compact(slices.Sorted(slices.Values(ids)))
+	docs := make([]*document.Document, 0, len(ids))
+	trashed := make([]string, 0, len(ids))
+	now := time.Now()
+	for _, id := range ids {
+		timeEntry := s.GetTimeEntry(id)
+		if timeEntry == nil {
+			continue
+		}
+
+		// A time entry deleted again after a restore replaces its old copy
+		s.RemoveFromTrash(id)
+
+		docs = append(docs, document.NewDocumentOf(&TrashEntry{
+			ID:        id,
+			TimeEntry: timeEntry,
+			DeletedAt: now,
+		}))
+		trashed = append(trashed, id)
+	}
+
+	if len(trashed) == 0 {
+		return 0
+	}
+
+	err := s.db.Insert(TrashCollection, docs...)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	s.DeleteTimeEntries(trashed)
+	return len(trashed)
+}
+
+// Returns the trashed time entries, latest deleted first
+func (s *Store) GetTrash() []*TrashEntry {
+	docs, err := s.db.FindAll(query.NewQuery(TrashCollection).Sort(query.SortOption{Field: "deleted_at", Direction: -1}))
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	entries := make([]*TrashEntry, len(docs))
+	for i, doc := range docs {
+		entries[i] = unmarshalTrashEntry(doc)
+	}
+	return entries
+}
+
+// Returns nil if the time entry is not in the trash
+func (s *Store) GetTrashEntry(id string) *TrashEntry {
+	doc, err := s.db.FindFirst(query.NewQuery(TrashCollection).Where(query.Field("id").Eq(id)))
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if doc == nil {
+		return nil
+	}
+	return unmarshalTrashEntry(doc)
+}
+
+func (s *Store) RemoveFromTrash(id string) {
+	err := s.db.Delete(query.NewQuery(TrashCollection).Where(query.Field("id").Eq(id)))
+	if err != nil {
+		log.Fatal(err)
+	}
+}
+
+// Permanently deletes the time entries trashed before the time. Returns the
+// number of deleted entries.
+func (s *Store) PurgeTrash(before time.Time) int {
+	q := query.NewQuery(TrashCollection).Where(query.Field("deleted_at").Lt(before))
+
+	count, err := s.db.Count(q)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	err = s.db.Delete(q)
+	if err != nil {
+		log.Fatal(err)
+	}
+	return count
+}
+
+func unmarshalTrashEntry(doc *document.Document) *TrashEntry {
+	entry := &TrashEntry{}
+	err := doc.Unmarshal(entry)
+	if err != nil {
+		log.Fatal(err)
+	}
+	return entry
+}
@@ -1,38 +1,74 @@
 package timeentry
 
 import (
+	"fmt"
+	"slices"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/gyurkovicsferi/time-tracker/lib/db"
 	s "github.com/gyurkovicsferi/time-tracker/lib/store"
+	"github.com/ostafen/clover/v2/query"
 )
 
-func Start(project, task string, store *s.Store) *s.CurrentTimeEntry {
+func Start(project, task string, store *s.Store) (*s.CurrentTimeEntry, error) {
 	return NewCurrentTimeEntry(store, project, task, time.Now())
 }
 
-func NewCurrentTimeEntry(store *s.Store, project, task string, start time.Time) *s.CurrentTimeEntry {
+func NewCurrentTimeEntry(store *s.Store, project, task string, start time.Time) (*s.CurrentTimeEntry, error) {
 	if store == nil {
 		store = s.NewStore(db.NewDB())
 		defer store.Close()
 	}
 
-	current := store.GetCurrentTimeEntry()
-
-	if current != nil {
-		Stop(store, current, time.Now())
-	}
-
-	currentTimeEntry := &s.CurrentTimeEntry{
+	return startTimeEntry(store, &s.CurrentTimeEntry{
 		ID:      uuid.New().String(),
 		Project: project,
 		Task:    task,
 		Start:   start,
+	})
+}
+
+// Starts a new time entry with the project, task, tags and note of the time
+// entry
+func Continue(store *s.Store, timeEntry *s.TimeEntry, start time.Time) (*s.CurrentTimeEntry, error) {
+	return startTimeEntry(store, &s.CurrentTimeEntry{
+		ID:      uuid.New().String(),
+		Project: timeEntry.Project,
+		Task:    timeEntry.Task,
+		Note:    timeEntry.Note,
+		Tags:    timeEntry.Tags,
+		Start:   start,
+	})
+}
+
+// Stops the running time entry at the start of the new one and starts it.
+// The start can't be in the future, before or at the start of the running
+// time entry, or overlap the finished time entries.
+func startTimeEntry(store *s.Store, currentTimeEntry *s.CurrentTimeEntry) (*s.CurrentTimeEntry, error) {
+	now := time.Now()
+	if currentTimeEntry.Start.After(now) {
+		return nil, fmt.Errorf("start time is in the future")
+	}
+
+	current := store.GetCurrentTimeEntry()
+	if current != nil && currentTimeEntry.Start.Before(current.Start) {
+		return nil, fmt.Errorf("start time is before the start of the running time entry (%s)", current.Start.Local().Format(time.DateTime))
+	}
+	// Stopping it would leave an entry without duration
+	if current != nil && currentTimeEntry.Start.Equal(current.Start) {
+		return nil, fmt.Errorf("%s / %s is running since the same time, change it with edit --running or discard it with cancel", current.Project, current.Task)
+	}
+	if err := checkOverlaps(store, &s.TimeEntry{ID: currentTimeEntry.ID, Start: currentTimeEntry.Start, End: now}); err != nil {
+		return nil, err
+	}
+
+	if current != nil {
+		currentTimeEntry.Stopped = Stop(store, current, currentTimeEntry.Start).ID
 	}
 
 	store.InsertCurrentTimeEntry(currentTimeEntry)
-	return currentTimeEntry
+	return currentTimeEntry, nil
 }
 
 func Stop(store *s.Store, currentTimeEntry *s.CurrentTimeEntry, end time.Time) *s.TimeEntry {
@@ -40,16 +76,215 @@ func Stop(store *s.Store, currentTimeEntry *s.CurrentTimeEntry, end time.Time) *
 		ID:      currentTimeEntry.ID,
 		Project: currentTimeEntry.Project,
 		Task:    currentTimeEntry.Task,
+		Note:    currentTimeEntry.Note,
+		Tags:    currentTimeEntry.Tags,
 		Start:   currentTimeEntry.Start,
 		End:     end,
 	}
 
 	store.InsertTimeEntry(timeEntry)
 	store.DeleteCurrentTimeEntry()
+	// The copy trashed when cancel --restore resumed it is outdated
+	store.RemoveFromTrash(timeEntry.ID)
+	store.EnqueueOutbox(timeEntry.ID, s.OutboxUpsert)
 
 	return timeEntry
 }
 
+// Discards the running time entry without recording it. With restore, the
+// time entry it stopped when it started is running again. Returns the
+// restored time entry.
+func Cancel(store *s.Store, current *s.CurrentTimeEntry, restore bool) (*s.CurrentTimeEntry, error) {
+	var stopped *s.TimeEntry
+	if restore {
+		if current.Stopped == "" {
+			return nil, fmt.Errorf("no time entry was stopped when the running one started")
+		}
+		stopped = store.GetTimeEntry(current.Stopped)
+		if stopped == nil {
+			return nil, fmt.Errorf("the time entry stopped when the running one started no longer exists")
+		}
+	}
+
+	store.DeleteCurrentTimeEntry()
+	if stopped == nil {
+		return nil, nil
+	}
+
+	// Trashed like every deletion, so undo or trash restore can bring it back
+	Delete(store, stopped.ID)
+
+	restored := &s.CurrentTimeEntry{
+		ID:      stopped.ID,
+		Project: stopped.Project,
+		Task:    stopped.Task,
+		Note:    stopped.Note,
+		Tags:    stopped.Tags,
+		Start:   stopped.Start,
+	}
+	store.InsertCurrentTimeEntry(restored)
+	return restored, nil
+}
+
+func Update(store *s.Store, timeEntry *s.TimeEntry) {
+	store.UpdateTimeEntry(timeEntry)
+	store.EnqueueOutbox(timeEntry.ID, s.OutboxUpsert)
+}
+
+// Moves the time entry to the trash, synced as a deletion
+func Delete(store *s.Store, id string) {
+	DeleteMany(store, []string{id})
+}
+
+// Moves the time entries to the trash as one operation, so they can be
+// restored with a single undo
+func DeleteMany(store *s.Store, ids []string) {
+	// A repeated id would be queued for deletion twice
+	ids = slices.Compact(slices.Sorted(slices.Values(ids)))
+	store.TrashTimeEntries(ids)
+	for _, id := range ids {
+		store.EnqueueOutbox(id, s.OutboxDelete)
+	}
+}
+
+// Moves the time entry back from the trash
+func Restore(store *s.Store, id string) (*s.TimeEntry, error) {
+	trashed := store.GetTrashEntry(id)
+	if trashed == nil {
+		return nil, fmt.Errorf("time entry %s is not in the trash", id)
+	}
+	if store.GetTimeEntry(id) != nil {
+		return nil, fmt.Errorf("time entry %s already exists", id)
+	}
+
+	store.InsertTimeEntry(trashed.TimeEntry)
+	store.RemoveFromTrash(id)
+	store.EnqueueOutbox(id, s.OutboxUpsert)
+	return trashed.TimeEntry, nil
+}
+
+// Changes of many time entries, applied in one transaction
+type Changes struct {
+	Create []*s.TimeEntry
+	Update []*s.TimeEntry
+	Delete []string
+}
+
+func (c *Changes) Empty() bool {
+	return len(c.Create) == 0 && len(c.Update) == 0 && len(c.Delete) == 0
+}
+
+// Validates all the changes first and applies them only if every one is
+// valid, in a single database transaction. They are recorded as one
+// operation, so a single undo reverts them.
+func Apply(store *s.Store, changes *Changes) error {
+	return store.Transaction(func() error {
+		if err := ValidateChanges(store, changes); err != nil {
+			return err
+		}
+
+		batch := store.Batch()
+		if len(changes.Delete) > 0 {
+			DeleteMany(batch, changes.Delete)
+		}
+		for _, timeEntry := range changes.Update {
+			Update(batch, timeEntry)
+		}
+		if len(changes.Create) > 0 {
+			batch.InsertTimeEntries(changes.Create)
+			for _, timeEntry := range changes.Create {
+				batch.EnqueueOutbox(timeEntry.ID, s.OutboxUpsert)
+			}
+		}
+		return nil
+	})
+}
+
+// Checks the created and updated time entries against each other and the
+// stored time entries they don't replace
+func ValidateChanges(store *s.Store, changes *Changes) error {
+	replaced := make(map[string]bool)
+	for _, id := range changes.Delete {
+		replaced[id] = true
+	}
+	for _, timeEntry := range changes.Update {
+		replaced[timeEntry.ID] = true
+	}
+
+	changed := append(slices.Clone(changes.Update), changes.Create...)
+	for i, timeEntry := range changed {
+		if !timeEntry.End.After(timeEntry.Start) {
+			return fmt.Errorf("%s / %s (%s): end time is not after start time", timeEntry.Project, timeEntry.Task, timeEntry.Start.Local().Format(time.DateTime))
+		}
+
+		others := slices.DeleteFunc(Overlapping(store, timeEntry), func(other *s.TimeEntry) bool {
+			return replaced[other.ID]
+		})
+		for _, other := range changed[i+1:] {
+			if other.Start.Before(timeEntry.End) && other.End.After(timeEntry.Start) {
+				others = append(others, other)
+			}
+		}
+		if len(others) > 0 {
+			return fmt.Errorf("%s / %s (%s) overlaps %s / %s (%s)",
+				timeEntry.Project, timeEntry.Task, timeEntry.Start.Local().Format(time.DateTime),
+				others[0].Project, others[0].Task, others[0].Start.Local().Format(time.DateTime))
+		}
+	}
+	return nil
+}
+
+// Checks that the time entry ends after it starts and doesn't overlap other
+// time entries
+func Validate(store *s.Store, timeEntry *s.TimeEntry) error {
+	if !timeEntry.End.After(timeEntry.Start) {
+		return fmt.Errorf("end time is not after start time")
+	}
+
+	return checkOverlaps(store, timeEntry)
+}
+
+func checkOverlaps(store *s.Store, timeEntry *s.TimeEntry) error {
+	overlapping := Overlapping(store, timeEntry)
+	if len(overlapping) > 0 {
+		other := overlapping[0]
+		return fmt.Errorf("overlaps %s / %s (%s - %s, id %s)",
+			other.Project, other.Task,
+			other.Start.Local().Format(time.DateTime), other.End.Local().Format(time.DateTime),
+			other.ID)
+	}
+	return nil
+}
+
+// Changes the running time entry. Its start can't be in the future or
+// overlap the finished time entries.
+func Amend(store *s.Store, current *s.CurrentTimeEntry) error {
+	if current.Project == "" {
+		return fmt.Errorf("project can't be empty")
+	}
+
+	now := time.Now()
+	if current.Start.After(now) {
+		return fmt.Errorf("start time is in the future")
+	}
+	if err := checkOverlaps(store, &s.TimeEntry{ID: current.ID, Start: current.Start, End: now}); err != nil {
+		return err
+	}
+
+	store.UpdateCurrentTimeEntry(current)
+	return nil
+}
+
+// Returns the other time entries that overlap the time entry
+func Overlapping(store *s.Store, timeEntry *s.TimeEntry) []*s.TimeEntry {
+	return store.GetTimeEntriesQuery(func(q *query.Query) *query.Query {
+		return q.Where(query.Field("start").Lt(timeEntry.End).
+			And(query.Field("end").Gt(timeEntry.Start)).
+			And(query.Field("id").Neq(timeEntry.ID))).
+			Sort(query.SortOption{Field: "start", Direction: 1})
+	})
+}
+
 func GetProjects(store *s.Store) []string {
 	return store.GetProjects()
 }
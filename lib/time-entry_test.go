@@ -0,0 +1,74 @@
+package timeentry
+
+import (
+	"testing"
+	"time"
+
+	s "github.com/gyurkovicsferi/time-tracker/lib/store"
+)
+
+func TestCancelRestoreTrashesTheStoppedEntry(t *testing.T) {
+	_, store := openStore(t)
+
+	now := time.Now()
+	first, err := NewCurrentTimeEntry(store, "Acme", "Development", now.Add(-2*time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := NewCurrentTimeEntry(store, "Acme", "Review", now.Add(-time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	restored, err := Cancel(store, second, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if restored.ID != first.ID {
+		t.Fatalf("restored %s, want %s", restored.ID, first.ID)
+	}
+	if store.GetTimeEntry(first.ID) != nil {
+		t.Error("the resumed time entry is still finished")
+	}
+	if store.GetTrashEntry(first.ID) == nil {
+		t.Error("the stopped time entry is not in the trash")
+	}
+
+	Stop(store, restored, now)
+	if store.GetTrashEntry(first.ID) != nil {
+		t.Error("the time entry stopped again is still in the trash")
+	}
+}
+
+func TestStartStopsTheRunningEntryAtTheNewStart(t *testing.T) {
+	_, store := openStore(t)
+
+	now := time.Now()
+	running, err := NewCurrentTimeEntry(store, "Acme", "Development", now.Add(-2*time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, start := range []time.Time{running.Start.Add(-time.Minute), running.Start, now.Add(time.Minute)} {
+		if _, err := Continue(store, &s.TimeEntry{Project: "Acme", Task: "Review"}, start); err == nil {
+			t.Errorf("started at %s while %s was running", start, running.Start)
+		}
+	}
+	if current := store.GetCurrentTimeEntry(); current == nil || current.ID != running.ID {
+		t.Fatal("the running time entry was replaced by a rejected start")
+	}
+
+	from := now.Add(-time.Hour)
+	if _, err := Continue(store, &s.TimeEntry{Project: "Acme", Task: "Review"}, from); err != nil {
+		t.Fatal(err)
+	}
+	if stopped := store.GetTimeEntry(running.ID); stopped == nil || !stopped.End.Equal(from) {
+		t.Errorf("got %+v, want it stopped at %s", stopped, from)
+	}
+
+	// The finished time entries can't be overlapped either
+	Stop(store, store.GetCurrentTimeEntry(), now.Add(-30*time.Minute))
+	if _, err := Continue(store, &s.TimeEntry{Project: "Acme", Task: "Call"}, now.Add(-90*time.Minute)); err == nil {
+		t.Error("started over a finished time entry")
+	}
+}
@@ -0,0 +1,420 @@
+// Package timewarrior reads and writes the interval files of Timewarrior
+// (data/YYYY-MM.data).
+//
+// A line of the files looks like:
+//
+//	inc 20261001T090000Z - 20261001T103000Z # project:acme "task:code review" "some tag" # "annotation"
+//
+// Timewarrior keeps tags as a set, not in the order they were given, so the
+// project and the task are the tags prefixed with project: and task:. The
+// other tags are the tags of the time entry, the annotation is the note.
+// Intervals without a prefixed tag, e.g. tracked with Timewarrior itself,
+// fall back to the first tag as the project and the second one as the task.
+package timewarrior
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io/fs"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/gyurkovicsferi/time-tracker/lib/importer"
+	"github.com/gyurkovicsferi/time-tracker/lib/store"
+)
+
+const (
+	timeLayout = "20060102T150405Z"
+
+	projectPrefix = "project:"
+	taskPrefix    = "task:"
+)
+
+type Interval struct {
+	Start time.Time
+	// Zero for the open (running) interval
+	End        time.Time
+	Tags       []string
+	Annotation string
+}
+
+func (i *Interval) IsOpen() bool {
+	return i.End.IsZero()
+}
+
+// Returns the Timewarrior database directory: $TIMEWARRIORDB or ~/.timewarrior
+func DefaultDir() string {
+	if dir := os.Getenv("TIMEWARRIORDB"); dir != "" {
+		return dir
+	}
+
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return ".timewarrior"
+	}
+	return filepath.Join(homeDir, ".timewarrior")
+}
+
+// Returns the data directory of the database, or dir itself if it is one
+func dataDir(dir string) string {
+	if info, err := os.Stat(filepath.Join(dir, "data")); err == nil && info.IsDir() {
+		return filepath.Join(dir, "data")
+	}
+	return dir
+}
+
+// Reads the intervals of every data file in the database, sorted by start
+func ReadDir(dir string) ([]*Interval, error) {
+	files, err := filepath.Glob(filepath.Join(dataDir(dir), "*.data"))
+	if err != nil {
+		return nil, err
+	}
+
+	intervals := make([]*Interval, 0)
+	for _, file := range files {
+		// tags.data is the tag index, not intervals
+		if filepath.Base(file) == "tags.data" {
+			continue
+		}
+
+		fileIntervals, err := ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		intervals = append(intervals, fileIntervals...)
+	}
+
+	slices.SortFunc(intervals, func(a, b *Interval) int {
+		return a.Start.Compare(b.Start)
+	})
+	return intervals, nil
+}
+
+func ReadFile(path string) ([]*Interval, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	intervals := make([]*Interval, 0)
+	scanner := bufio.NewScanner(file)
+	for line := 1; scanner.Scan(); line++ {
+		if strings.TrimSpace(scanner.Text()) == "" {
+			continue
+		}
+
+		interval, err := ParseLine(scanner.Text())
+		if err != nil {
+			return nil, fmt.Errorf("%s:%d: %v", path, line, err)
+		}
+		intervals = append(intervals, interval)
+	}
+
+	return intervals, scanner.Err()
+}
+
+func ParseLine(line string) (*Interval, error) {
+	tokens, err := tokenize(line)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(tokens) < 2 || !tokens[0].is("inc") {
+		return nil, fmt.Errorf("expected an inc interval")
+	}
+
+	interval := &Interval{}
+	interval.Start, err = time.Parse(timeLayout, tokens[1].text)
+	if err != nil {
+		return nil, fmt.Errorf("invalid start: %v", err)
+	}
+	tokens = tokens[2:]
+
+	if len(tokens) >= 2 && tokens[0].is("-") {
+		interval.End, err = time.Parse(timeLayout, tokens[1].text)
+		if err != nil {
+			return nil, fmt.Errorf("invalid end: %v", err)
+		}
+		if interval.End.Before(interval.Start) {
+			return nil, fmt.Errorf("end is before start")
+		}
+		tokens = tokens[2:]
+	}
+
+	if len(tokens) == 0 {
+		return interval, nil
+	}
+	if !tokens[0].is("#") {
+		return nil, fmt.Errorf("unexpected %q", tokens[0].text)
+	}
+	tokens = tokens[1:]
+
+	interval.Tags = make([]string, 0, len(tokens))
+	for i, token := range tokens {
+		if token.is("#") {
+			annotation := make([]string, 0, len(tokens)-i-1)
+			for _, token := range tokens[i+1:] {
+				annotation = append(annotation, token.text)
+			}
+			interval.Annotation = strings.Join(annotation, " ")
+			break
+		}
+		interval.Tags = append(interval.Tags, token.text)
+	}
+
+	return interval, nil
+}
+
+type token struct {
+	text   string
+	quoted bool
+}
+
+// Whether the token is the unquoted keyword, so a "#" tag is not a separator
+func (t token) is(keyword string) bool {
+	return !t.quoted && t.text == keyword
+}
+
+// Splits the line at spaces, keeping double quoted strings together
+func tokenize(line string) ([]token, error) {
+	tokens := make([]token, 0)
+	var text strings.Builder
+	inToken, inQuotes, quoted, escaped := false, false, false, false
+
+	for _, r := range line {
+		switch {
+		case escaped:
+			text.WriteRune(r)
+			escaped = false
+		case inQuotes && r == '\\':
+			escaped = true
+		case r == '"':
+			inQuotes = !inQuotes
+			inToken, quoted = true, true
+		case !inQuotes && (r == ' ' || r == '\t'):
+			if inToken {
+				tokens = append(tokens, token{text: text.String(), quoted: quoted})
+				text.Reset()
+				inToken, quoted = false, false
+			}
+		default:
+			text.WriteRune(r)
+			inToken = true
+		}
+	}
+
+	if inQuotes {
+		return nil, fmt.Errorf("unterminated quote")
+	}
+	if inToken {
+		tokens = append(tokens, token{text: text.String(), quoted: quoted})
+	}
+	return tokens, nil
+}
+
+func (i *Interval) String() string {
+	var line strings.Builder
+	line.WriteString("inc " + i.Start.UTC().Format(timeLayout))
+	if !i.IsOpen() {
+		line.WriteString(" - " + i.End.UTC().Format(timeLayout))
+	}
+
+	if len(i.Tags) > 0 || i.Annotation != "" {
+		line.WriteString(" #")
+		for _, tag := range i.Tags {
+			line.WriteString(" " + quote(tag, false))
+		}
+	}
+	if i.Annotation != "" {
+		line.WriteString(" # " + quote(i.Annotation, true))
+	}
+
+	return line.String()
+}
+
+func quote(s string, always bool) string {
+	if !always && s != "" && s != "#" && !strings.ContainsAny(s, " \t\"\\") {
+		return s
+	}
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s) + `"`
+}
+
+// Writes the intervals, exported from the from - to range, to the data files
+// of their month in dir, creating the data directory if needed. If any of the
+// files exists nothing is written, unless overwrite is set: then the
+// intervals of the existing files that start in the range are replaced and
+// the others, e.g. tracked with Timewarrior itself, are kept. Returns the
+// written files.
+func WriteDir(dir string, intervals []*Interval, from, to time.Time, overwrite bool) ([]string, error) {
+	dir = filepath.Join(dir, "data")
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+
+	byMonth := make(map[string][]*Interval)
+	for _, interval := range intervals {
+		month := interval.Start.UTC().Format("2006-01")
+		byMonth[month] = append(byMonth[month], interval)
+	}
+
+	if !overwrite {
+		for _, month := range slices.Sorted(maps.Keys(byMonth)) {
+			path := filepath.Join(dir, month+".data")
+			if _, err := os.Stat(path); err == nil {
+				return nil, fmt.Errorf("%s already exists", path)
+			}
+		}
+	} else {
+		// The existing files of the range are rewritten even without exported
+		// intervals, to drop the ones deleted since an earlier export
+		first := time.Date(from.UTC().Year(), from.UTC().Month(), 1, 0, 0, 0, 0, time.UTC)
+		for month := first; !month.After(to); month = month.AddDate(0, 1, 0) {
+			key := month.Format("2006-01")
+			if _, ok := byMonth[key]; ok {
+				continue
+			}
+			if _, err := os.Stat(filepath.Join(dir, key+".data")); err == nil {
+				byMonth[key] = nil
+			}
+		}
+
+		// Every file is read before any is written
+		for month := range byMonth {
+			existing, err := ReadFile(filepath.Join(dir, month+".data"))
+			if errors.Is(err, fs.ErrNotExist) {
+				continue
+			}
+			if err != nil {
+				return nil, err
+			}
+
+			for _, interval := range existing {
+				if interval.Start.Before(from) || interval.Start.After(to) {
+					byMonth[month] = append(byMonth[month], interval)
+				}
+			}
+		}
+	}
+
+	months := slices.Sorted(maps.Keys(byMonth))
+	files := make([]string, 0, len(months))
+	for _, month := range months {
+		path := filepath.Join(dir, month+".data")
+		monthIntervals := byMonth[month]
+		slices.SortFunc(monthIntervals, func(a, b *Interval) int {
+			return a.Start.Compare(b.Start)
+		})
+
+		var content strings.Builder
+		for _, interval := range monthIntervals {
+			content.WriteString(interval.String() + "\n")
+		}
+
+		if err := os.WriteFile(path, []byte(content.String()), 0644); err != nil {
+			return files, err
+		}
+		files = append(files, path)
+	}
+
+	return files, nil
+}
+
+// Returns the project, task, tags and note of the interval
+func (i *Interval) fields() (string, string, []string, string) {
+	project, task := "", ""
+	var tags []string
+	prefixed := false
+	for _, tag := range i.Tags {
+		if value, ok := strings.CutPrefix(tag, projectPrefix); ok && project == "" {
+			project, prefixed = value, true
+		} else if value, ok := strings.CutPrefix(tag, taskPrefix); ok && task == "" {
+			task, prefixed = value, true
+		} else {
+			tags = append(tags, tag)
+		}
+	}
+
+	if !prefixed {
+		project, task, tags = "", "", nil
+		if len(i.Tags) > 0 {
+			project = i.Tags[0]
+		}
+		if len(i.Tags) > 1 {
+			task = i.Tags[1]
+		}
+		if len(i.Tags) > 2 {
+			tags = slices.Clone(i.Tags[2:])
+		}
+	}
+
+	if project == "" {
+		project = importer.NoProject
+	}
+	return project, task, tags, i.Annotation
+}
+
+// Returns nil for the open interval
+func (i *Interval) ToTimeEntry() *store.TimeEntry {
+	if i.IsOpen() {
+		return nil
+	}
+
+	project, task, tags, note := i.fields()
+	return &store.TimeEntry{
+		ID:      uuid.New().String(),
+		Project: project,
+		Task:    task,
+		Note:    note,
+		Tags:    tags,
+		Start:   i.Start.Local(),
+		End:     i.End.Local(),
+	}
+}
+
+func (i *Interval) ToCurrentTimeEntry() *store.CurrentTimeEntry {
+	project, task, tags, note := i.fields()
+	return &store.CurrentTimeEntry{
+		ID:      uuid.New().String(),
+		Project: project,
+		Task:    task,
+		Note:    note,
+		Tags:    tags,
+		Start:   i.Start.Local(),
+	}
+}
+
+func FromTimeEntry(timeEntry *store.TimeEntry) *Interval {
+	return &Interval{
+		Start:      timeEntry.Start,
+		End:        timeEntry.End,
+		Tags:       tagsOf(timeEntry.Project, timeEntry.Task, timeEntry.Tags),
+		Annotation: timeEntry.Note,
+	}
+}
+
+func FromCurrentTimeEntry(current *store.CurrentTimeEntry) *Interval {
+	return &Interval{
+		Start:      current.Start,
+		Tags:       tagsOf(current.Project, current.Task, current.Tags),
+		Annotation: current.Note,
+	}
+}
+
+// The project and the task are prefixed, and left out when they are empty
+func tagsOf(project, task string, tags []string) []string {
+	result := make([]string, 0, len(tags)+2)
+	if project != "" {
+		result = append(result, projectPrefix+project)
+	}
+	if task != "" {
+		result = append(result, taskPrefix+task)
+	}
+	return append(result, tags...)
+}
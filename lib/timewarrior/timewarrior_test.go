@@ -0,0 +1,112 @@
+package timewarrior
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/gyurkovicsferi/time-tracker/lib/store"
+)
+
+func TestTagsRoundTrip(t *testing.T) {
+	start := time.Date(2026, 10, 1, 9, 0, 0, 0, time.UTC)
+	for _, timeEntry := range []*store.TimeEntry{
+		{Project: "Acme", Task: "Code review", Tags: []string{"billable"}, Note: "PR 12"},
+		{Project: "Acme", Tags: []string{"billable"}},
+		{Project: "Acme"},
+	} {
+		timeEntry.Start, timeEntry.End = start, start.Add(time.Hour)
+
+		line := FromTimeEntry(timeEntry).String()
+		interval, err := ParseLine(line)
+		if err != nil {
+			t.Fatal(err)
+		}
+		// Timewarrior doesn't keep the order of the tags
+		slices.Reverse(interval.Tags)
+
+		got := interval.ToTimeEntry()
+		if got.Project != timeEntry.Project || got.Task != timeEntry.Task || got.Note != timeEntry.Note || !slices.Equal(got.Tags, timeEntry.Tags) {
+			t.Errorf("%s: got %s / %s %v %q", line, got.Project, got.Task, got.Tags, got.Note)
+		}
+	}
+}
+
+func TestUnprefixedTags(t *testing.T) {
+	interval, err := ParseLine("inc 20261001T090000Z - 20261001T100000Z # acme coding billable")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got := interval.ToTimeEntry()
+	if got.Project != "acme" || got.Task != "coding" || !slices.Equal(got.Tags, []string{"billable"}) {
+		t.Errorf("got %s / %s %v", got.Project, got.Task, got.Tags)
+	}
+}
+
+func TestWriteDirChecksEveryFileFirst(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(dir, "data"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	existing := filepath.Join(dir, "data", "2026-11.data")
+	if err := os.WriteFile(existing, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	intervals := []*Interval{
+		{Start: time.Date(2026, 10, 1, 9, 0, 0, 0, time.UTC), End: time.Date(2026, 10, 1, 10, 0, 0, 0, time.UTC)},
+		{Start: time.Date(2026, 11, 1, 9, 0, 0, 0, time.UTC), End: time.Date(2026, 11, 1, 10, 0, 0, 0, time.UTC)},
+	}
+	from, to := time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC), time.Date(2026, 11, 30, 0, 0, 0, 0, time.UTC)
+	if _, err := WriteDir(dir, intervals, from, to, false); err == nil {
+		t.Fatal("expected an error for the existing file")
+	}
+	if _, err := os.Stat(filepath.Join(dir, "data", "2026-10.data")); !os.IsNotExist(err) {
+		t.Error("an earlier month was written before the error")
+	}
+
+	files, err := WriteDir(dir, intervals, from, to, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 2 {
+		t.Errorf("wrote %d files, want 2", len(files))
+	}
+}
+
+func TestWriteDirKeepsIntervalsOutsideTheRange(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(dir, "data"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	existing := filepath.Join(dir, "data", "2026-10.data")
+	content := "inc 20261001T090000Z - 20261001T100000Z # outside\n" +
+		"inc 20261010T090000Z - 20261010T100000Z # replaced\n" +
+		"inc 20261020T090000Z - 20261020T100000Z # timewarrior\n"
+	if err := os.WriteFile(existing, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	from, to := time.Date(2026, 10, 5, 0, 0, 0, 0, time.UTC), time.Date(2026, 10, 15, 0, 0, 0, 0, time.UTC)
+	intervals := []*Interval{
+		{Start: time.Date(2026, 10, 12, 9, 0, 0, 0, time.UTC), End: time.Date(2026, 10, 12, 10, 0, 0, 0, time.UTC), Tags: []string{"exported"}},
+	}
+	if _, err := WriteDir(dir, intervals, from, to, true); err != nil {
+		t.Fatal(err)
+	}
+
+	written, err := ReadFile(existing)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tags := make([]string, len(written))
+	for i, interval := range written {
+		tags[i] = interval.Tags[0]
+	}
+	if want := []string{"outside", "exported", "timewarrior"}; !slices.Equal(tags, want) {
+		t.Errorf("got %q, want %q", tags, want)
+	}
+}
@@ -0,0 +1,270 @@
+// Package toggl reads and writes the CSV and JSON exports of Toggl Track.
+package toggl
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/gyurkovicsferi/time-tracker/lib/importer"
+	"github.com/gyurkovicsferi/time-tracker/lib/store"
+)
+
+const (
+	dateLayout = "2006-01-02"
+	timeLayout = "15:04:05"
+)
+
+// The columns of the Toggl detailed report, in the order they are exported
+var csvHeader = []string{
+	"Email", "Client", "Project", "Task", "Description", "Billable",
+	"Start date", "Start time", "End date", "End time", "Duration", "Tags",
+}
+
+// A time entry of the Toggl JSON export
+type jsonEntry struct {
+	Project     string   `json:"project"`
+	Task        string   `json:"task,omitempty"`
+	Description string   `json:"description"`
+	Tags        []string `json:"tags"`
+	Start       string   `json:"start"`
+	Stop        string   `json:"stop,omitempty"`
+	End         string   `json:"end,omitempty"`
+	// Seconds
+	Duration int64 `json:"duration,omitempty"`
+	// Milliseconds, used by the detailed report
+	Dur int64 `json:"dur,omitempty"`
+}
+
+// Reads a Toggl CSV export. Dates and times without a zone are in loc.
+func ReadCSV(r io.Reader, loc *time.Location) ([]*store.TimeEntry, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the CSV header: %v", err)
+	}
+
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))] = i
+	}
+	for _, required := range []string{"start date", "start time"} {
+		if _, ok := columns[required]; !ok {
+			return nil, fmt.Errorf("missing column %q", required)
+		}
+	}
+
+	timeEntries := make([]*store.TimeEntry, 0)
+	for line := 2; ; line++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			return timeEntries, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %v", line, err)
+		}
+
+		get := func(column string) string {
+			i, ok := columns[column]
+			if !ok || i >= len(record) {
+				return ""
+			}
+			return strings.TrimSpace(record[i])
+		}
+
+		start, err := time.ParseInLocation(dateLayout+" "+timeLayout, get("start date")+" "+get("start time"), loc)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: invalid start: %v", line, err)
+		}
+
+		var end time.Time
+		if get("end date") != "" && get("end time") != "" {
+			end, err = time.ParseInLocation(dateLayout+" "+timeLayout, get("end date")+" "+get("end time"), loc)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: invalid end: %v", line, err)
+			}
+		} else {
+			duration, err := ParseDuration(get("duration"))
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %v", line, err)
+			}
+			end = start.Add(duration)
+		}
+
+		if end.Before(start) {
+			return nil, fmt.Errorf("line %d: end is before start", line)
+		}
+
+		timeEntries = append(timeEntries, newTimeEntry(get("project"), get("task"), get("description"), splitTags(get("tags")), start, end))
+	}
+}
+
+// Writes the entries as a Toggl CSV, which Toggl can import. Toggl requires
+// the email of the user for every entry.
+func WriteCSV(w io.Writer, timeEntries []*store.TimeEntry, email string) error {
+	writer := csv.NewWriter(w)
+	if err := writer.Write(csvHeader); err != nil {
+		return err
+	}
+
+	for _, timeEntry := range timeEntries {
+		err := writer.Write([]string{
+			email,
+			"",
+			timeEntry.Project,
+			timeEntry.Task,
+			timeEntry.Note,
+			"No",
+			timeEntry.Start.Format(dateLayout),
+			timeEntry.Start.Format(timeLayout),
+			timeEntry.End.Format(dateLayout),
+			timeEntry.End.Format(timeLayout),
+			FormatDuration(timeEntry.End.Sub(timeEntry.Start)),
+			strings.Join(timeEntry.Tags, ", "),
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
+
+// Reads a Toggl JSON export: an array of time entries with RFC 3339 times
+func ReadJSON(r io.Reader) ([]*store.TimeEntry, error) {
+	var entries []jsonEntry
+	if err := json.NewDecoder(r).Decode(&entries); err != nil {
+		return nil, fmt.Errorf("failed to decode the JSON export: %v", err)
+	}
+
+	timeEntries := make([]*store.TimeEntry, 0, len(entries))
+	for i, entry := range entries {
+		start, err := time.Parse(time.RFC3339, entry.Start)
+		if err != nil {
+			return nil, fmt.Errorf("entry %d: invalid start: %v", i+1, err)
+		}
+
+		stop := entry.Stop
+		if stop == "" {
+			stop = entry.End
+		}
+
+		var end time.Time
+		switch {
+		case stop != "":
+			end, err = time.Parse(time.RFC3339, stop)
+			if err != nil {
+				return nil, fmt.Errorf("entry %d: invalid stop: %v", i+1, err)
+			}
+		case entry.Duration > 0:
+			end = start.Add(time.Duration(entry.Duration) * time.Second)
+		case entry.Dur > 0:
+			end = start.Add(time.Duration(entry.Dur) * time.Millisecond)
+		default:
+			// Running entries have a negative duration and no stop
+			continue
+		}
+
+		if end.Before(start) {
+			return nil, fmt.Errorf("entry %d: stop is before start", i+1)
+		}
+
+		timeEntries = append(timeEntries, newTimeEntry(entry.Project, entry.Task, entry.Description, entry.Tags, start.Local(), end.Local()))
+	}
+
+	return timeEntries, nil
+}
+
+func WriteJSON(w io.Writer, timeEntries []*store.TimeEntry) error {
+	entries := make([]jsonEntry, len(timeEntries))
+	for i, timeEntry := range timeEntries {
+		tags := timeEntry.Tags
+		if tags == nil {
+			tags = []string{}
+		}
+		entries[i] = jsonEntry{
+			Project:     timeEntry.Project,
+			Task:        timeEntry.Task,
+			Description: timeEntry.Note,
+			Tags:        tags,
+			Start:       timeEntry.Start.Format(time.RFC3339),
+			Stop:        timeEntry.End.Format(time.RFC3339),
+			Duration:    int64(timeEntry.End.Sub(timeEntry.Start) / time.Second),
+		}
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(entries)
+}
+
+// Toggl tasks are a paid feature, so most entries only have a description.
+// That becomes the task then, otherwise it is kept as the note. An exported
+// entry with a note but no task is read back with the note as its task, the
+// import skips it as a duplicate of the original all the same.
+func newTimeEntry(project, task, description string, tags []string, start, end time.Time) *store.TimeEntry {
+	if project == "" {
+		project = importer.NoProject
+	}
+
+	note := description
+	if task == "" {
+		task, note = description, ""
+	}
+
+	return &store.TimeEntry{
+		ID:      uuid.New().String(),
+		Project: project,
+		Task:    task,
+		Note:    note,
+		Tags:    tags,
+		Start:   start,
+		End:     end,
+	}
+}
+
+func splitTags(tags string) []string {
+	if tags == "" {
+		return nil
+	}
+
+	split := strings.Split(tags, ",")
+	result := make([]string, 0, len(split))
+	for _, tag := range split {
+		if tag = strings.TrimSpace(tag); tag != "" {
+			result = append(result, tag)
+		}
+	}
+	return result
+}
+
+// Parses a Toggl duration: hh:mm:ss, where hours can exceed 24
+func ParseDuration(duration string) (time.Duration, error) {
+	parts := strings.Split(duration, ":")
+	if len(parts) != 3 {
+		return 0, fmt.Errorf("invalid duration %q, expected hh:mm:ss", duration)
+	}
+
+	var total time.Duration
+	for i, unit := range []time.Duration{time.Hour, time.Minute, time.Second} {
+		value, err := strconv.Atoi(parts[i])
+		if err != nil || value < 0 {
+			return 0, fmt.Errorf("invalid duration %q, expected hh:mm:ss", duration)
+		}
+		total += time.Duration(value) * unit
+	}
+	return total, nil
+}
+
+func FormatDuration(d time.Duration) string {
+	d = d.Round(time.Second)
+	return fmt.Sprintf("%02d:%02d:%02d", int(d.Hours()), int(d.Minutes())%60, int(d.Seconds())%60)
+}
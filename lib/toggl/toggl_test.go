@@ -0,0 +1,78 @@
+package toggl
+
+import (
+	"bytes"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/gyurkovicsferi/time-tracker/lib/importer"
+	"github.com/gyurkovicsferi/time-tracker/lib/store"
+	"github.com/ostafen/clover/v2"
+)
+
+func testTimeEntries() []*store.TimeEntry {
+	start := time.Date(2026, 10, 1, 9, 0, 0, 0, time.Local)
+	return []*store.TimeEntry{
+		{ID: "1", Project: "Acme", Task: "Development", Start: start, End: start.Add(time.Hour)},
+		{ID: "2", Project: "Acme", Task: "Review", Note: "PR 12", Tags: []string{"billable"}, Start: start.Add(time.Hour), End: start.Add(2 * time.Hour)},
+		{ID: "3", Project: "Acme", Note: "Call with the client", Start: start.Add(2 * time.Hour), End: start.Add(3 * time.Hour)},
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	for _, format := range []struct {
+		name  string
+		write func(*bytes.Buffer, []*store.TimeEntry) error
+		read  func(*bytes.Buffer) ([]*store.TimeEntry, error)
+	}{
+		{
+			name:  "csv",
+			write: func(b *bytes.Buffer, e []*store.TimeEntry) error { return WriteCSV(b, e, "me@example.com") },
+			read:  func(b *bytes.Buffer) ([]*store.TimeEntry, error) { return ReadCSV(b, time.Local) },
+		},
+		{
+			name:  "json",
+			write: func(b *bytes.Buffer, e []*store.TimeEntry) error { return WriteJSON(b, e) },
+			read:  func(b *bytes.Buffer) ([]*store.TimeEntry, error) { return ReadJSON(b) },
+		},
+	} {
+		t.Run(format.name, func(t *testing.T) {
+			db, err := clover.Open(t.TempDir())
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer db.Close()
+			s := store.NewStore(db)
+
+			timeEntries := testTimeEntries()
+			s.InsertTimeEntries(timeEntries)
+
+			var buf bytes.Buffer
+			if err := format.write(&buf, timeEntries); err != nil {
+				t.Fatal(err)
+			}
+			imported, err := format.read(&buf)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(imported) != len(timeEntries) {
+				t.Fatalf("read %d time entries, want %d", len(imported), len(timeEntries))
+			}
+
+			for i, timeEntry := range timeEntries[:2] {
+				got := imported[i]
+				if got.Project != timeEntry.Project || got.Task != timeEntry.Task || got.Note != timeEntry.Note ||
+					!slices.Equal(got.Tags, timeEntry.Tags) || !got.Start.Equal(timeEntry.Start) || !got.End.Equal(timeEntry.End) {
+					t.Errorf("got %+v, want %+v", got, timeEntry)
+				}
+			}
+
+			for _, candidate := range importer.Plan(s, imported) {
+				if !candidate.Duplicate {
+					t.Errorf("the re-import of %s / %s / %s is not a duplicate", candidate.TimeEntry.Project, candidate.TimeEntry.Task, candidate.TimeEntry.Note)
+				}
+			}
+		})
+	}
+}
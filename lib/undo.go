@@ -0,0 +1,101 @@
+package timeentry
+
+import (
+	"fmt"
+	"slices"
+
+	s "github.com/gyurkovicsferi/time-tracker/lib/store"
+)
+
+// Reverts the last n operations, latest first, in a single transaction. Each
+// change is only reverted if its time entry is still as the change left it,
+// unless force is set; otherwise nothing is reverted. Returns the reverted
+// changes.
+func Undo(store *s.Store, n int, force bool) ([]*s.HistoryEntry, error) {
+	operations := store.GetUndoableOperations(n)
+	if len(operations) == 0 {
+		return nil, fmt.Errorf("nothing to undo")
+	}
+
+	undone := make([]*s.HistoryEntry, 0)
+	err := store.Transaction(func() error {
+		// Checked one by one after the later changes are reverted, as they
+		// may have changed the same time entries
+		for _, changes := range operations {
+			undoStore := store.ForUndo(changes[0].Group)
+			for _, change := range changes {
+				if !force {
+					if err := checkUndo(store, change); err != nil {
+						return err
+					}
+				}
+				undoChange(undoStore, change)
+			}
+			store.MarkUndone(changes[0].Group)
+			undone = append(undone, changes...)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return undone, nil
+}
+
+// Checks that the time entry is still as the change left it
+func checkUndo(store *s.Store, change *s.HistoryEntry) error {
+	current := store.GetTimeEntry(change.TimeEntryID)
+
+	switch change.Operation {
+	case s.HistoryDelete:
+		if current != nil {
+			return fmt.Errorf("time entry %s has been recreated since it was deleted, use --force to undo anyway", change.TimeEntryID)
+		}
+		// Resumed by cancel --restore
+		if running := store.GetCurrentTimeEntry(); running != nil && running.ID == change.TimeEntryID {
+			return fmt.Errorf("time entry %s is running, stop it first", change.TimeEntryID)
+		}
+	default:
+		if current == nil {
+			return fmt.Errorf("time entry %s has been deleted since, use --force to undo anyway", change.TimeEntryID)
+		}
+		if !Equal(current, change.After) {
+			return fmt.Errorf("time entry %s has been changed since, use --force to undo anyway", change.TimeEntryID)
+		}
+	}
+	return nil
+}
+
+func undoChange(store *s.Store, change *s.HistoryEntry) {
+	switch change.Operation {
+	case s.HistoryCreate:
+		Delete(store, change.TimeEntryID)
+	case s.HistoryUpdate:
+		if store.GetTimeEntry(change.TimeEntryID) == nil {
+			store.InsertTimeEntry(change.Before)
+			store.EnqueueOutbox(change.TimeEntryID, s.OutboxUpsert)
+			return
+		}
+		Update(store, change.Before)
+	case s.HistoryDelete:
+		if store.GetTimeEntry(change.TimeEntryID) != nil {
+			Update(store, change.Before)
+			return
+		}
+		store.InsertTimeEntry(change.Before)
+		store.RemoveFromTrash(change.TimeEntryID)
+		store.EnqueueOutbox(change.TimeEntryID, s.OutboxUpsert)
+	}
+}
+
+// Whether the time entries have the same fields
+func Equal(a, b *s.TimeEntry) bool {
+	return a.ID == b.ID &&
+		a.Project == b.Project &&
+		a.Task == b.Task &&
+		a.Note == b.Note &&
+		slices.Equal(a.Tags, b.Tags) &&
+		a.Start.Equal(b.Start) &&
+		a.End.Equal(b.End)
+}
@@ -0,0 +1,85 @@
+package timeentry
+
+import (
+	"testing"
+	"time"
+
+	"github.com/gyurkovicsferi/time-tracker/lib/db"
+	s "github.com/gyurkovicsferi/time-tracker/lib/store"
+	"github.com/ostafen/clover/v2"
+	"github.com/ostafen/clover/v2/query"
+)
+
+func openStore(t *testing.T) (*clover.DB, *s.Store) {
+	cdb, err := db.Open(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { cdb.Close() })
+	return cdb, s.NewStore(cdb)
+}
+
+func testTimeEntry(id string, hour int) *s.TimeEntry {
+	start := time.Date(2026, 10, 1, hour, 0, 0, 0, time.UTC)
+	return &s.TimeEntry{ID: id, Project: "Acme", Task: "Development", Start: start, End: start.Add(time.Hour)}
+}
+
+func TestUndoEditsOfTheSameEntry(t *testing.T) {
+	_, store := openStore(t)
+
+	original := testTimeEntry("1", 9)
+	store.InsertTimeEntry(original)
+
+	first := *original
+	first.Note = "first"
+	Update(store, &first)
+	second := first
+	second.Note = "second"
+	Update(store, &second)
+
+	undone, err := Undo(store, 2, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(undone) != 2 {
+		t.Errorf("undone %d changes, want 2", len(undone))
+	}
+	if got := store.GetTimeEntry("1"); got == nil || !Equal(got, original) {
+		t.Errorf("got %+v, want %+v", got, original)
+	}
+
+	// The create is undoable after the edits
+	if _, err := Undo(store, 1, false); err != nil {
+		t.Fatal(err)
+	}
+	if store.GetTimeEntry("1") != nil {
+		t.Error("the created time entry wasn't deleted")
+	}
+}
+
+func TestFailedUndoRevertsNothing(t *testing.T) {
+	cdb, store := openStore(t)
+
+	store.InsertTimeEntry(testTimeEntry("1", 9))
+	store.InsertTimeEntry(testTimeEntry("2", 10))
+	edited := testTimeEntry("2", 10)
+	edited.Note = "edited"
+	Update(store, edited)
+
+	// Changed without the history, so its create can't be undone
+	err := cdb.Update(query.NewQuery(s.TimeEntryCollection).Where(query.Field("id").Eq("1")), map[string]interface{}{"note": "changed"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := Undo(store, 3, false); err == nil {
+		t.Fatal("expected the changed time entry to stop the undo")
+	}
+
+	if got := store.GetTimeEntry("2"); got == nil || got.Note != "edited" {
+		t.Errorf("got %+v, the later operations were reverted", got)
+	}
+	if operations := store.GetUndoableOperations(3); len(operations) != 3 {
+		t.Errorf("got %d undoable operations, want 3", len(operations))
+	}
+}